@@ -0,0 +1,340 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AgeQualifier represents the comparison of an age value
+type AgeQualifier int
+
+const (
+	AgeExact   AgeQualifier = iota // no qualifier
+	AgeLess                        // <, less than the age
+	AgeGreater                     // >, greater than the age
+)
+
+// AgeKeyword represents a keyword age value
+type AgeKeyword int
+
+const (
+	AgeNoKeyword AgeKeyword = iota // years, months and days
+	AgeChild                       // CHILD, less than 8 years
+	AgeInfant                      // INFANT, less than 1 year
+	AgeStillborn                   // STILLBORN, at or near birth
+)
+
+var ageKeywords = [...]string{
+	AgeNoKeyword: "",
+	AgeChild:     "CHILD",
+	AgeInfant:    "INFANT",
+	AgeStillborn: "STILLBORN",
+}
+
+// String returns the GEDCOM keyword
+func (k AgeKeyword) String() string {
+	if k >= 0 && int(k) < len(ageKeywords) {
+		return ageKeywords[k]
+	}
+	return fmt.Sprintf("AgeKeyword(%d)", int(k))
+}
+
+// AgeUnit represents the unit of an age value
+type AgeUnit int
+
+const (
+	AgeYears  AgeUnit = iota // y
+	AgeMonths                // m
+	AgeDays                  // d
+)
+
+// Age represents a GEDCOM age value like "< 30y 6m" or "INFANT"
+type Age struct {
+	Qualifier AgeQualifier // <, > or none
+	Keyword   AgeKeyword   // CHILD, INFANT or STILLBORN, or none
+	Years     int          // years
+	Months    int          // months
+	Days      int          // days
+	Precision AgeUnit      // smallest unit of the value
+}
+
+// ParseAge parses a GEDCOM age value. A number without a unit is taken
+// as years.
+func ParseAge(value string) (*Age, error) {
+	a := &Age{}
+	s := strings.TrimSpace(value)
+
+	switch {
+	case strings.HasPrefix(s, "<"):
+		a.Qualifier = AgeLess
+		s = strings.TrimSpace(s[1:])
+	case strings.HasPrefix(s, ">"):
+		a.Qualifier = AgeGreater
+		s = strings.TrimSpace(s[1:])
+	}
+	if s == "" {
+		return nil, &AgeError{value, "missing age"}
+	}
+
+	for k, keyword := range ageKeywords {
+		if k != int(AgeNoKeyword) && strings.EqualFold(s, keyword) {
+			if a.Qualifier != AgeExact {
+				return nil, &AgeError{value, "qualifier with " + keyword}
+			}
+			a.Keyword = AgeKeyword(k)
+			return a, nil
+		}
+	}
+
+	seen := -1
+	for _, tok := range strings.Fields(s) {
+		for tok != "" {
+			i := 0
+			for i < len(tok) && tok[i] >= '0' && tok[i] <= '9' {
+				i++
+			}
+			if i == 0 {
+				return nil, &AgeError{value, "expected a number at " + strconv.Quote(tok)}
+			}
+			n, err := strconv.Atoi(tok[:i])
+			if err != nil {
+				return nil, &AgeError{value, err.Error()}
+			}
+
+			unit := AgeYears
+			if i < len(tok) {
+				switch tok[i] {
+				case 'y', 'Y':
+					unit = AgeYears
+				case 'm', 'M':
+					unit = AgeMonths
+				case 'd', 'D':
+					unit = AgeDays
+				default:
+					return nil, &AgeError{value, "unknown unit at " + strconv.Quote(tok[i:])}
+				}
+				i++
+			} else if seen >= 0 {
+				return nil, &AgeError{value, "missing unit after " + tok}
+			}
+			if int(unit) <= seen {
+				return nil, &AgeError{value, "units out of order"}
+			}
+			seen = int(unit)
+
+			switch unit {
+			case AgeYears:
+				a.Years = n
+			case AgeMonths:
+				a.Months = n
+			case AgeDays:
+				a.Days = n
+			}
+			a.Precision = unit
+			tok = tok[i:]
+		}
+	}
+
+	return a, nil
+}
+
+// String formats the age canonically
+func (a *Age) String() string {
+	if a.Keyword != AgeNoKeyword {
+		return a.Keyword.String()
+	}
+
+	var ss []string
+
+	switch a.Qualifier {
+	case AgeLess:
+		ss = append(ss, "<")
+	case AgeGreater:
+		ss = append(ss, ">")
+	}
+	if a.Years != 0 || a.Precision == AgeYears {
+		ss = append(ss, strconv.Itoa(a.Years)+"y")
+	}
+	if a.Months != 0 || a.Precision == AgeMonths {
+		ss = append(ss, strconv.Itoa(a.Months)+"m")
+	}
+	if a.Days != 0 || a.Precision == AgeDays {
+		ss = append(ss, strconv.Itoa(a.Days)+"d")
+	}
+
+	return strings.Join(ss, " ")
+}
+
+// ageSpan is a length of time in calendar units
+type ageSpan struct {
+	years, months, days int
+}
+
+// nominal returns the span in millionths of an average day, for ordering
+func (s ageSpan) nominal() int64 {
+	return int64(s.years*12+s.months)*30436875 + int64(s.days)*1000000
+}
+
+// span returns the youngest age and the age just beyond the oldest the
+// value could be; ok reports whether there is an oldest age
+func (a *Age) span() (low, high ageSpan, ok bool) {
+	switch a.Keyword {
+	case AgeChild:
+		return ageSpan{}, ageSpan{years: 8}, true
+	case AgeInfant:
+		return ageSpan{}, ageSpan{years: 1}, true
+	case AgeStillborn:
+		return ageSpan{}, ageSpan{days: 1}, true
+	}
+
+	stated := ageSpan{a.Years, a.Months, a.Days}
+	switch a.Qualifier {
+	case AgeLess:
+		return ageSpan{}, stated, true
+	case AgeGreater:
+		stated.days++
+		return stated, ageSpan{}, false
+	}
+
+	high = stated
+	switch a.Precision {
+	case AgeYears:
+		high.years++
+	case AgeMonths:
+		high.months++
+	default:
+		high.days++
+	}
+	return stated, high, true
+}
+
+// Compare orders two ages by the youngest each could be, then by the
+// oldest, returning -1, 0 or +1
+func (a *Age) Compare(b *Age) int {
+	lowA, highA, okA := a.span()
+	lowB, highB, okB := b.span()
+
+	switch na, nb := lowA.nominal(), lowB.nominal(); {
+	case na < nb:
+		return -1
+	case na > nb:
+		return 1
+	}
+	switch na, nb := highA.nominal(), highB.nominal(); {
+	case !okA && !okB:
+		return 0
+	case !okA:
+		return 1
+	case !okB:
+		return -1
+	case na < nb:
+		return -1
+	case na > nb:
+		return 1
+	}
+	return 0
+}
+
+// Less reports whether a orders before b
+func (a *Age) Less(b *Age) bool {
+	return a.Compare(b) < 0
+}
+
+// ParsedAge parses the age of the event
+func (r *EventRecord) ParsedAge() (*Age, error) {
+	return ParseAge(r.Age)
+}
+
+// ParsedAge parses the age of the attribute
+func (r *AttributeRecord) ParsedAge() (*Age, error) {
+	return ParseAge(r.Age)
+}
+
+// ParsedAge parses the age of the individual at the event
+func (r *IndividualLink) ParsedAge() (*Age, error) {
+	return ParseAge(r.Age)
+}
+
+// EstimateBirth returns the range of Gregorian dates on which someone of
+// the given age at the date of an event could have been born: a BET date,
+// a BEF or AFT date when one end is open, or a single date
+func EstimateBirth(date *ParsedDate, age *Age) (*ParsedDate, error) {
+	earliest, latest, okEarliest, okLatest := date.Bounds()
+	low, high, okHigh := age.span()
+
+	if okEarliest && okHigh {
+		earliest = subtractAge(earliest, high) + 1
+	} else {
+		okEarliest = false
+	}
+	if okLatest {
+		latest = subtractAge(latest, low)
+	}
+
+	p := &ParsedDate{}
+	var err error
+	switch {
+	case okEarliest && okLatest && earliest == latest:
+		p.Date1, err = FromJDN(earliest, Gregorian)
+	case okEarliest && okLatest:
+		p.Kind = DateBetween
+		if p.Date1, err = FromJDN(earliest, Gregorian); err == nil {
+			p.Date2, err = FromJDN(latest, Gregorian)
+		}
+	case okLatest:
+		p.Kind = DateBefore
+		p.Date1, err = FromJDN(latest+1, Gregorian)
+	case okEarliest:
+		p.Kind = DateAfter
+		p.Date1, err = FromJDN(earliest-1, Gregorian)
+	default:
+		return nil, &DateError{date.String(), "cannot estimate a birth date"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// EstimateBirth returns the range of dates on which the subject of the
+// event could have been born, from the date and age of the event
+func (r *EventRecord) EstimateBirth() (*ParsedDate, error) {
+	if r.Date == nil {
+		return nil, &DateError{"", "missing date"}
+	}
+	date, err := r.Date.Parse()
+	if err != nil {
+		return nil, err
+	}
+	age, err := r.ParsedAge()
+	if err != nil {
+		return nil, err
+	}
+	return EstimateBirth(date, age)
+}
+
+// subtractAge returns the Julian Day Number a span of time before another,
+// counting years and months in the Gregorian calendar
+func subtractAge(jdn int, s ageSpan) int {
+	year, month, day := jdnToGregorian(jdn)
+
+	month -= s.years*12 + s.months
+	year += floorDiv(month-1, 12)
+	month = floorMod(month-1, 12) + 1
+	d := CalendarDate{Month: month, Year: year}
+	if year <= 0 {
+		d = CalendarDate{Month: month, Year: 1 - year, BC: true}
+	}
+	if n := d.daysInMonth(); day > n {
+		day = n
+	}
+
+	return gregorianToJDN(year, month, day) - s.days
+}
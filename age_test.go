@@ -0,0 +1,139 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+var ageExamples = []struct {
+	input string
+	want  Age
+	str   string
+}{
+	{"30y", Age{Years: 30}, "30y"},
+	{"< 30y 6m", Age{Qualifier: AgeLess, Years: 30, Months: 6, Precision: AgeMonths}, ""},
+	{">1y", Age{Qualifier: AgeGreater, Years: 1}, "> 1y"},
+	{"6m 2d", Age{Months: 6, Days: 2, Precision: AgeDays}, ""},
+	{"30y 0m", Age{Years: 30, Precision: AgeMonths}, ""},
+	{"0d", Age{Precision: AgeDays}, ""},
+	{"40", Age{Years: 40}, "40y"},
+	{"45Y 3M", Age{Years: 45, Months: 3, Precision: AgeMonths}, "45y 3m"},
+	{"INFANT", Age{Keyword: AgeInfant}, ""},
+	{"child", Age{Keyword: AgeChild}, "CHILD"},
+	{"STILLBORN", Age{Keyword: AgeStillborn}, ""},
+}
+
+func TestParseAge(t *testing.T) {
+	for _, ex := range ageExamples {
+		a, err := ParseAge(ex.input)
+		if err != nil {
+			t.Errorf("ParseAge(%q) returned error %v, expected no error", ex.input, err)
+			continue
+		}
+		if diff := deep.Equal(*a, ex.want); diff != nil {
+			t.Errorf("ParseAge(%q): %v", ex.input, diff)
+		}
+		str := ex.str
+		if str == "" {
+			str = ex.input
+		}
+		if a.String() != str {
+			t.Errorf("ParseAge(%q).String() = %q, expected %q", ex.input, a.String(), str)
+		}
+	}
+}
+
+var badAges = []string{
+	"",
+	"<",
+	"< INFANT",
+	"6m 30y",
+	"30y 30y",
+	"30 6",
+	"30x",
+	"about 30",
+}
+
+func TestParseAgeErrors(t *testing.T) {
+	for _, input := range badAges {
+		if a, err := ParseAge(input); err == nil {
+			t.Errorf("ParseAge(%q) = %v, expected an error", input, a)
+		} else if _, ok := err.(*AgeError); !ok {
+			t.Errorf("ParseAge(%q) returned %T, expected *AgeError", input, err)
+		}
+	}
+}
+
+func TestCompareAges(t *testing.T) {
+	ordered := []string{"STILLBORN", "< 6m", "INFANT", "CHILD", "< 30y", "29y", "30y", "> 30y", "31y"}
+	for i := range ordered {
+		for j := range ordered {
+			a, _ := ParseAge(ordered[i])
+			b, _ := ParseAge(ordered[j])
+			want := 0
+			switch {
+			case i < j:
+				want = -1
+			case i > j:
+				want = 1
+			}
+			if got := a.Compare(b); got != want {
+				t.Errorf("Compare(%q, %q) = %d, expected %d", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+}
+
+var birthExamples = []struct {
+	date string
+	age  string
+	want string
+}{
+	{"15 JUN 1900", "30y", "BET 16 JUN 1869 AND 15 JUN 1870"},
+	{"15 JUN 1900", "30y 6m", "BET 16 NOV 1869 AND 15 DEC 1869"},
+	{"15 JUN 1900", "30y 6m 2d", "13 DEC 1869"},
+	{"1900", "< 30y", "BET 2 JAN 1870 AND 31 DEC 1900"},
+	{"15 JUN 1900", "> 30y", "BEF 15 JUN 1870"},
+	{"BEF 1900", "30y", "BEF 1 JAN 1870"},
+	{"AFT 1900", "30y", "AFT 1 JAN 1870"},
+	{"31 MAR 1900", "1m", "BET 1 FEB 1900 AND 28 FEB 1900"},
+	{"31 MAR 101 B.C.", "1m", "BET 1 FEB 101 B.C. AND 28 FEB 101 B.C."},
+	{"10 MAY 1900", "STILLBORN", "10 MAY 1900"},
+	{"10 MAY 1900", "INFANT", "BET 11 MAY 1899 AND 10 MAY 1900"},
+}
+
+func TestEstimateBirth(t *testing.T) {
+	for _, ex := range birthExamples {
+		date, err := ParseDate(ex.date)
+		if err != nil {
+			t.Fatalf("ParseDate(%q) returned error %v", ex.date, err)
+		}
+		age, err := ParseAge(ex.age)
+		if err != nil {
+			t.Fatalf("ParseAge(%q) returned error %v", ex.age, err)
+		}
+		birth, err := EstimateBirth(date, age)
+		if err != nil {
+			t.Errorf("EstimateBirth(%q, %q) returned error %v", ex.date, ex.age, err)
+			continue
+		}
+		if birth.String() != ex.want {
+			t.Errorf("EstimateBirth(%q, %q) = %q, expected %q", ex.date, ex.age, birth.String(), ex.want)
+		}
+	}
+
+	if _, err := EstimateBirth(&ParsedDate{Kind: DatePhrase, Phrase: "unknown"}, &Age{Years: 30}); err == nil {
+		t.Errorf("EstimateBirth of a date phrase returned no error")
+	}
+
+	r := &EventRecord{Date: &DateRecord{Date: "1 JAN 1900"}, Age: "10y"}
+	if birth, err := r.EstimateBirth(); err != nil || birth.String() != "BET 2 JAN 1889 AND 1 JAN 1890" {
+		t.Errorf("EventRecord.EstimateBirth() = %v, %v", birth, err)
+	}
+}
@@ -0,0 +1,440 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"fmt"
+	"sort"
+)
+
+// AboutYears is how far either side of an ABT, CAL or EST date its
+// earliest and latest days are taken to be
+var AboutYears = 10
+
+// JDN returns the Julian Day Number of the first day of the date
+func (d CalendarDate) JDN() (int, error) {
+	first, _, err := d.Bounds()
+	return first, err
+}
+
+// Bounds returns the Julian Day Numbers of the first and last days of the
+// date, which are different when the day or month is missing
+func (d CalendarDate) Bounds() (first int, last int, err error) {
+	year := d.Year
+	if d.DualYear != 0 {
+		year = d.DualYear
+	}
+	if d.BC {
+		year = 1 - year // astronomical year numbering
+	}
+
+	switch d.Calendar {
+	case Gregorian, Julian:
+		toJDN := gregorianToJDN
+		if d.Calendar == Julian {
+			toJDN = julianToJDN
+		}
+		switch {
+		case d.Month == 0:
+			return toJDN(year, 1, 1), toJDN(year+1, 1, 1) - 1, nil
+		case d.Day == 0:
+			return toJDN(year, d.Month, 1), toJDN(year, d.Month, 1) + d.daysInMonth() - 1, nil
+		}
+		jdn := toJDN(year, d.Month, d.Day)
+		return jdn, jdn, nil
+
+	case Hebrew:
+		switch {
+		case d.Month == 0:
+			return hebrewToJDN(year, 7, 1), hebrewToJDN(year+1, 7, 1) - 1, nil
+		case d.Day == 0:
+			month := hebrewMonth(year, d.Month)
+			first = hebrewToJDN(year, month, 1)
+			return first, first + hebrewMonthDays(year, month) - 1, nil
+		}
+		jdn := hebrewToJDN(year, hebrewMonth(year, d.Month), d.Day)
+		return jdn, jdn, nil
+
+	case French:
+		switch {
+		case d.Month == 0:
+			return frenchToJDN(year, 1, 1), frenchToJDN(year+1, 1, 1) - 1, nil
+		case d.Day == 0:
+			first = frenchToJDN(year, d.Month, 1)
+			if d.Month == 13 {
+				return first, frenchToJDN(year+1, 1, 1) - 1, nil
+			}
+			return first, first + 29, nil
+		}
+		jdn := frenchToJDN(year, d.Month, d.Day)
+		return jdn, jdn, nil
+	}
+
+	return 0, 0, fmt.Errorf("gedcom: cannot convert dates in calendar %s", d.Calendar)
+}
+
+// FromJDN returns the date in calendar cal of a Julian Day Number
+func FromJDN(jdn int, cal Calendar) (CalendarDate, error) {
+	var year, month, day int
+
+	switch cal {
+	case Gregorian:
+		year, month, day = jdnToGregorian(jdn)
+	case Julian:
+		year, month, day = jdnToJulian(jdn)
+	case Hebrew:
+		year, month, day = jdnToHebrew(jdn)
+		month = hebrewGedcomMonth(year, month)
+	case French:
+		year, month, day = jdnToFrench(jdn)
+	default:
+		return CalendarDate{}, fmt.Errorf("gedcom: cannot convert dates in calendar %s", cal)
+	}
+
+	d := CalendarDate{Calendar: cal, Day: day, Month: month, Year: year}
+	if year <= 0 {
+		d.Year, d.BC = 1-year, true
+	}
+	return d, nil
+}
+
+// gregorianToJDN converts a proleptic Gregorian date to a Julian Day Number
+func gregorianToJDN(year, month, day int) int {
+	a := (14 - month) / 12
+	y := year + 4800 - a
+	m := month + 12*a - 3
+	return day + (153*m+2)/5 + 365*y + floorDiv(y, 4) - floorDiv(y, 100) + floorDiv(y, 400) - 32045
+}
+
+// julianToJDN converts a Julian calendar date to a Julian Day Number
+func julianToJDN(year, month, day int) int {
+	a := (14 - month) / 12
+	y := year + 4800 - a
+	m := month + 12*a - 3
+	return day + (153*m+2)/5 + 365*y + floorDiv(y, 4) - 32083
+}
+
+// jdnToGregorian converts a Julian Day Number to a proleptic Gregorian date
+func jdnToGregorian(jdn int) (year, month, day int) {
+	a := jdn + 32044
+	b := floorDiv(4*a+3, 146097)
+	c := a - floorDiv(146097*b, 4)
+	return jdnToYMD(b, c)
+}
+
+// jdnToJulian converts a Julian Day Number to a Julian calendar date
+func jdnToJulian(jdn int) (year, month, day int) {
+	return jdnToYMD(0, jdn+32082)
+}
+
+// jdnToYMD completes the conversion of a Julian Day Number after the
+// calendar's century correction
+func jdnToYMD(b, c int) (year, month, day int) {
+	d := floorDiv(4*c+3, 1461)
+	e := c - floorDiv(1461*d, 4)
+	m := (5*e + 2) / 153
+	day = e - (153*m+2)/5 + 1
+	month = m + 3 - 12*(m/10)
+	year = 100*b + d - 4800 + m/10
+	return year, month, day
+}
+
+// floorDiv divides rounding towards minus infinity
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
+
+// The Hebrew calendar functions number the months from Nisan as 1, so
+// that Tishri, the first month of the year, is 7, Adar is 12 and Adar
+// Sheni is 13. GEDCOM numbers them from Tishri as 1.
+
+// hebrewEpoch is the Julian Day Number of the day before 1 Tishri 1
+const hebrewEpoch = 347997
+
+// hebrewMonth converts a GEDCOM Hebrew month to a month from Nisan
+func hebrewMonth(year, month int) int {
+	if month == 7 && !hebrewLeap(year) { // ADS in a common year is Adar
+		return 12
+	}
+	if month <= 7 {
+		return month + 6
+	}
+	return month - 7
+}
+
+// hebrewGedcomMonth converts a month from Nisan to a GEDCOM Hebrew month
+func hebrewGedcomMonth(year, month int) int {
+	if month >= 7 {
+		return month - 6
+	}
+	return month + 7
+}
+
+// hebrewLeap reports whether a Hebrew year has thirteen months
+func hebrewLeap(year int) bool {
+	return floorMod(7*year+1, 19) < 7
+}
+
+// hebrewYearMonths returns the number of months in a Hebrew year
+func hebrewYearMonths(year int) int {
+	if hebrewLeap(year) {
+		return 13
+	}
+	return 12
+}
+
+// hebrewElapsedDays returns the days from the epoch to the molad of
+// Tishri of a Hebrew year, delayed when it falls on Sunday, Wednesday
+// or Friday
+func hebrewElapsedDays(year int) int {
+	months := floorDiv(235*year-234, 19)
+	parts := 12084 + 13753*months
+	day := months*29 + floorDiv(parts, 25920)
+	if floorMod(3*(day+1), 7) < 3 {
+		day++
+	}
+	return day
+}
+
+// hebrewYearDelay returns the further delay of 1 Tishri which keeps
+// the lengths of the Hebrew years in range
+func hebrewYearDelay(year int) int {
+	last := hebrewElapsedDays(year - 1)
+	present := hebrewElapsedDays(year)
+	next := hebrewElapsedDays(year + 1)
+	switch {
+	case next-present == 356:
+		return 2
+	case present-last == 382:
+		return 1
+	}
+	return 0
+}
+
+// hebrewNewYear returns the Julian Day Number of 1 Tishri of a Hebrew year
+func hebrewNewYear(year int) int {
+	return hebrewEpoch + hebrewElapsedDays(year) + hebrewYearDelay(year) + 1
+}
+
+// hebrewYearDays returns the number of days in a Hebrew year
+func hebrewYearDays(year int) int {
+	return hebrewNewYear(year+1) - hebrewNewYear(year)
+}
+
+// hebrewMonthDays returns the number of days in a month from Nisan
+func hebrewMonthDays(year, month int) int {
+	switch {
+	case month == 2 || month == 4 || month == 6 || month == 10 || month == 13:
+		return 29
+	case month == 12 && !hebrewLeap(year):
+		return 29
+	case month == 8 && hebrewYearDays(year)%10 != 5: // Heshvan
+		return 29
+	case month == 9 && hebrewYearDays(year)%10 == 3: // Kislev
+		return 29
+	}
+	return 30
+}
+
+// hebrewToJDN converts a Hebrew date, with the month from Nisan, to a Julian Day Number
+func hebrewToJDN(year, month, day int) int {
+	jdn := hebrewNewYear(year) + day - 1
+	if month < 7 {
+		for m := 7; m <= hebrewYearMonths(year); m++ {
+			jdn += hebrewMonthDays(year, m)
+		}
+		for m := 1; m < month; m++ {
+			jdn += hebrewMonthDays(year, m)
+		}
+	} else {
+		for m := 7; m < month; m++ {
+			jdn += hebrewMonthDays(year, m)
+		}
+	}
+	return jdn
+}
+
+// jdnToHebrew converts a Julian Day Number to a Hebrew date, with the month from Nisan
+func jdnToHebrew(jdn int) (year, month, day int) {
+	year = int(int64(jdn-hebrewEpoch)*98496/35975351) - 1
+	for jdn >= hebrewNewYear(year+1) {
+		year++
+	}
+
+	month = 7
+	if jdn < hebrewToJDN(year, 1, 1) {
+		for jdn > hebrewToJDN(year, month, hebrewMonthDays(year, month)) {
+			month++
+		}
+	} else {
+		month = 1
+		for jdn > hebrewToJDN(year, month, hebrewMonthDays(year, month)) {
+			month++
+		}
+	}
+	day = jdn - hebrewToJDN(year, month, 1) + 1
+	return year, month, day
+}
+
+// frenchEpoch places 1 Vendémiaire I on Julian Day Number 2375840,
+// 22 September 1792
+const frenchEpoch = 2375474
+
+// frenchToJDN converts a French Republican date to a Julian Day Number.
+// Years 3, 7 and 11 are leap years, as they were in use.
+func frenchToJDN(year, month, day int) int {
+	return floorDiv(year*1461, 4) + (month-1)*30 + day + frenchEpoch
+}
+
+// jdnToFrench converts a Julian Day Number to a French Republican date
+func jdnToFrench(jdn int) (year, month, day int) {
+	temp := (jdn-frenchEpoch)*4 - 1
+	year = floorDiv(temp, 1461)
+	dayOfYear := floorMod(temp, 1461) / 4
+	return year, dayOfYear/30 + 1, dayOfYear%30 + 1
+}
+
+// floorMod returns the remainder of floorDiv
+func floorMod(a, b int) int {
+	return a - floorDiv(a, b)*b
+}
+
+// Bounds returns the Julian Day Numbers of the earliest and latest days
+// the date value could be. The earliest of BEF and TO dates and the latest
+// of AFT and FROM dates are open, as is everything about a date phrase;
+// ok reports whether each end is known.
+func (p *ParsedDate) Bounds() (earliest int, latest int, okEarliest bool, okLatest bool) {
+	if p.Kind == DatePhrase {
+		return 0, 0, false, false
+	}
+
+	first, last, err := p.Date1.Bounds()
+	if err != nil {
+		return 0, 0, false, false
+	}
+
+	switch p.Kind {
+	case DateBefore:
+		return 0, first - 1, false, true
+	case DateAfter:
+		return last + 1, 0, true, false
+	case DateTo:
+		return 0, last, false, true
+	case DateFrom:
+		return first, 0, true, false
+	case DateBetween, DateFromTo:
+		_, last2, err := p.Date2.Bounds()
+		if err != nil {
+			return first, 0, true, false
+		}
+		return first, last2, true, true
+	}
+
+	switch p.Qualifier {
+	case QualifierAbout, QualifierCalculated, QualifierEstimated:
+		days := AboutYears*365 + AboutYears/4
+		first -= days
+		last += days
+	}
+	return first, last, true, true
+}
+
+// Earliest returns the Julian Day Number of the earliest day the date could be
+func (p *ParsedDate) Earliest() (int, bool) {
+	earliest, _, ok, _ := p.Bounds()
+	return earliest, ok
+}
+
+// Latest returns the Julian Day Number of the latest day the date could be
+func (p *ParsedDate) Latest() (int, bool) {
+	_, latest, _, ok := p.Bounds()
+	return latest, ok
+}
+
+// SortKey returns a Julian Day Number for ordering dates: the first day of
+// the date, the day before a BEF date or the day after an AFT date. It
+// reports false for a phrase or a calendar which cannot be converted.
+func (p *ParsedDate) SortKey() (int, bool) {
+	if p.Kind == DatePhrase {
+		return 0, false
+	}
+	first, last, err := p.Date1.Bounds()
+	if err != nil {
+		return 0, false
+	}
+	switch p.Kind {
+	case DateBefore:
+		return first - 1, true
+	case DateAfter:
+		return last + 1, true
+	}
+	return first, true
+}
+
+// Compare orders two dates by SortKey, returning -1, 0 or +1. Dates
+// without a sort key come after those with one.
+func (p *ParsedDate) Compare(q *ParsedDate) int {
+	kp, okp := p.SortKey()
+	kq, okq := q.SortKey()
+	switch {
+	case !okp && !okq:
+		return 0
+	case !okq:
+		return -1
+	case !okp:
+		return 1
+	case kp < kq:
+		return -1
+	case kp > kq:
+		return 1
+	}
+	return 0
+}
+
+// Before reports whether p is certainly before q: the latest day p could
+// be is earlier than the earliest day q could be
+func (p *ParsedDate) Before(q *ParsedDate) bool {
+	latest, ok := p.Latest()
+	if !ok {
+		return false
+	}
+	earliest, ok := q.Earliest()
+	return ok && latest < earliest
+}
+
+// SortKey returns the sort key of the date of the event; it reports false
+// if the event has no date or the date cannot be parsed
+func (r *EventRecord) SortKey() (int, bool) {
+	if r.Date == nil {
+		return 0, false
+	}
+	p, err := r.Date.Parse()
+	if err != nil {
+		return 0, false
+	}
+	return p.SortKey()
+}
+
+// SortByDate sorts the events by date, keeping the order of events with
+// equal dates. Events without a usable date go last.
+func (r EventRecords) SortByDate() {
+	keys := make(map[*EventRecord]int, len(r))
+	oks := make(map[*EventRecord]bool, len(r))
+	for _, e := range r {
+		keys[e], oks[e] = e.SortKey()
+	}
+	sort.SliceStable(r, func(i, j int) bool {
+		a, b := r[i], r[j]
+		if oks[a] != oks[b] {
+			return oks[a]
+		}
+		return oks[a] && keys[a] < keys[b]
+	})
+}
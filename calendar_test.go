@@ -0,0 +1,152 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"testing"
+)
+
+var jdnExamples = []struct {
+	date string
+	jdn  int
+}{
+	{"1 JAN 2000", 2451545},
+	{"15 OCT 1582", 2299161},
+	{"@#DJULIAN@ 4 OCT 1582", 2299160},
+	{"@#DJULIAN@ 1 JAN 4713 B.C.", 0},
+	{"1 JAN 1 B.C.", 1721060},
+	{"29 FEB 401 B.C.", 1575022},
+	{"@#DJULIAN@ 29 FEB 101 B.C.", 1684592},
+	{"@#DJULIAN@ 11 FEB 1731/32", 2353712}, // Washington's birth, 22 Feb 1732 Gregorian
+	{"@#DHEBREW@ 1 TSH 5785", 2460587},
+	{"@#DHEBREW@ 15 NSN 5784", 2460424},
+	{"@#DHEBREW@ 14 ADS 5784", 2460394}, // Purim in a leap year
+	{"@#DHEBREW@ 14 ADR 5783", 2460011}, // Purim in a common year
+	{"@#DFRENCH R@ 1 VEND 1", 2375840},
+	{"@#DFRENCH R@ 18 BRUM 8", 2378444}, // 9 Nov 1799
+	{"@#DFRENCH R@ 6 COMP 3", 2376935},
+}
+
+func TestJDN(t *testing.T) {
+	for _, ex := range jdnExamples {
+		p, err := ParseDate(ex.date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		jdn, err := p.Date1.JDN()
+		if err != nil || jdn != ex.jdn {
+			t.Errorf("JDN of %s = %d, %v, expected %d", ex.date, jdn, err, ex.jdn)
+		}
+
+		d, err := FromJDN(jdn, p.Date1.Calendar)
+		if err != nil {
+			t.Errorf("FromJDN(%d) returned error %v", jdn, err)
+		}
+		d.DualYear = p.Date1.DualYear
+		if d.DualYear != 0 {
+			d.Year--
+		}
+		if d != p.Date1 {
+			t.Errorf("FromJDN(%d) = %s, expected %s", jdn, d, p.Date1)
+		}
+	}
+}
+
+func TestJDNRoundTrip(t *testing.T) {
+	for _, cal := range []Calendar{Gregorian, Julian, Hebrew, French} {
+		for jdn := 2300000; jdn < 2500000; jdn += 7 {
+			d, err := FromJDN(jdn, cal)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := d.JDN(); got != jdn {
+				t.Fatalf("%s: FromJDN(%d) = %s, which converts back to %d", cal, jdn, d, got)
+			}
+		}
+	}
+}
+
+func TestBounds(t *testing.T) {
+	examples := []struct {
+		date              string
+		earliest, latest  int
+		okEarly, okLatest bool
+	}{
+		{"1900", 2415021, 2415385, true, true},
+		{"FEB 1900", 2415052, 2415079, true, true},
+		{"BEF 1900", 0, 2415020, false, true},
+		{"AFT 1900", 2415386, 0, true, false},
+		{"BET 1900 AND 1901", 2415021, 2415750, true, true},
+		{"ABT 1 JAN 1900", 2415021 - 3652, 2415021 + 3652, true, true},
+		{"(unknown)", 0, 0, false, false},
+	}
+	for _, ex := range examples {
+		p, err := ParseDate(ex.date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, l, oke, okl := p.Bounds()
+		if e != ex.earliest || l != ex.latest || oke != ex.okEarly || okl != ex.okLatest {
+			t.Errorf("Bounds of %s = %d, %d, %v, %v, expected %d, %d, %v, %v",
+				ex.date, e, l, oke, okl, ex.earliest, ex.latest, ex.okEarly, ex.okLatest)
+		}
+	}
+}
+
+func TestCompareDates(t *testing.T) {
+	parse := func(s string) *ParsedDate {
+		p, err := ParseDate(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	if parse("BEF 1900").Compare(parse("1900")) != -1 {
+		t.Errorf("BEF 1900 does not sort before 1900")
+	}
+	if parse("AFT 1900").Compare(parse("31 DEC 1900")) != 1 {
+		t.Errorf("AFT 1900 does not sort after 31 DEC 1900")
+	}
+	if parse("@#DJULIAN@ 1 JAN 1900").Compare(parse("13 JAN 1900")) != 0 {
+		t.Errorf("Julian 1 JAN 1900 is not Gregorian 13 JAN 1900")
+	}
+	if parse("(phrase)").Compare(parse("1900")) != 1 {
+		t.Errorf("a phrase does not sort after a date")
+	}
+
+	if !parse("1850").Before(parse("1851")) {
+		t.Errorf("1850 is not before 1851")
+	}
+	if parse("ABT 1850").Before(parse("1851")) {
+		t.Errorf("ABT 1850 is certainly before 1851")
+	}
+	if parse("BEF 1900").Before(parse("AFT 1800")) {
+		t.Errorf("BEF 1900 is certainly before AFT 1800")
+	}
+}
+
+func TestSortEvents(t *testing.T) {
+	events := EventRecords{
+		{Tag: "DEAT", Date: &DateRecord{Date: "1900"}},
+		{Tag: "RESI"},
+		{Tag: "BIRT", Date: &DateRecord{Date: "ABT 1850"}},
+		{Tag: "MARR", Date: &DateRecord{Date: "AFT 1870"}},
+		{Tag: "BAPM", Date: &DateRecord{Date: "BEF 1851"}},
+	}
+	events.SortByDate()
+
+	var tags []string
+	for _, e := range events {
+		tags = append(tags, e.Tag)
+	}
+	want := []string{"BIRT", "BAPM", "MARR", "DEAT", "RESI"}
+	for i := range want {
+		if tags[i] != want[i] {
+			t.Fatalf("SortByDate gave %v, expected %v", tags, want)
+		}
+	}
+}
@@ -0,0 +1,456 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Character sets recognised by the Decoder
+const (
+	CharsetANSEL     = "ANSEL"
+	CharsetASCII     = "ASCII"
+	CharsetANSI      = "ANSI"
+	CharsetUTF8      = "UTF-8"
+	CharsetUTF16LE   = "UTF-16LE"
+	CharsetUTF16BE   = "UTF-16BE"
+	CharsetMacintosh = "MACINTOSH"
+	CharsetIBMPC     = "IBMPC"
+)
+
+// sniffSize is the number of bytes examined to find the character set
+const sniffSize = 4096
+
+// charsetDecoder converts src to UTF-8, appending it to dst. It returns the
+// number of bytes of src converted; an incomplete character at the end of
+// src is left for the next call unless atEOF.
+type charsetDecoder func(dst []byte, src []byte, atEOF bool) ([]byte, int)
+
+// charsetReader converts its input to UTF-8 as it is read
+type charsetReader struct {
+	r         io.Reader
+	decode    charsetDecoder
+	unit      int  // bytes of input for each ASCII character
+	bigEndian bool // UTF-16 is big endian
+	buf       []byte
+	in        []byte       // input not yet converted
+	out       []byte       // converted output not yet returned
+	err       error        // error from r
+	inPos     int64        // input converted, in bytes
+	outPos    int64        // output converted, in bytes
+	eol       offsetPair   // offsets after the last terminator used
+	eols      []offsetPair // offsets after line terminators not yet used
+}
+
+// offsetPair is an offset in the output of a charsetReader and the
+// matching offset in its input
+type offsetPair struct {
+	out, in int64
+}
+
+// Read reads UTF-8 into p
+func (c *charsetReader) Read(p []byte) (n int, err error) {
+	for len(c.out) == 0 && c.err == nil {
+		var m int
+		m, c.err = c.r.Read(c.buf)
+		c.in = append(c.in, c.buf[:m]...)
+		var used int
+		c.out, used = c.decode(c.out[:0], c.in, c.err != nil)
+		c.mark(c.in[:used], c.out)
+		c.in = c.in[:copy(c.in, c.in[used:])]
+	}
+
+	n = copy(p, c.out)
+	c.out = c.out[n:]
+	if n == 0 {
+		return 0, c.err
+	}
+	return n, nil
+}
+
+// mark records the offsets after each line terminator in out, which was
+// converted from in. Terminators are ASCII in every character set, and
+// each one in the input gives one in the output.
+func (c *charsetReader) mark(in []byte, out []byte) {
+	var ins []int64
+	for i := 0; i+c.unit <= len(in); i += c.unit {
+		var b byte
+		switch {
+		case c.unit == 1:
+			b = in[i]
+		case c.bigEndian && in[i] == 0:
+			b = in[i+1]
+		case !c.bigEndian && in[i+1] == 0:
+			b = in[i]
+		}
+		if b == '\n' || b == '\r' {
+			ins = append(ins, c.inPos+int64(i+c.unit))
+		}
+	}
+	for i, b := range out {
+		if (b == '\n' || b == '\r') && len(ins) > 0 {
+			c.eols = append(c.eols, offsetPair{out: c.outPos + int64(i+1), in: ins[0]})
+			ins = ins[1:]
+		}
+	}
+	c.inPos += int64(len(in))
+	c.outPos += int64(len(out))
+}
+
+// inputOffset returns the offset in the input of the start of a line at
+// offset out in the output. It must be called with increasing offsets.
+func (c *charsetReader) inputOffset(out int64) int64 {
+	for len(c.eols) > 0 && c.eols[0].out <= out {
+		c.eol, c.eols = c.eols[0], c.eols[1:]
+	}
+	// only ASCII white space comes between a terminator and a line
+	return c.eol.in + (out-c.eol.out)*int64(c.unit)
+}
+
+// charsetReader returns a reader which converts r to UTF-8. The character
+// set is found from a byte order mark, from the pattern of zero bytes in
+// UTF-16, or from the HEAD.CHAR line.
+func (d *Decoder) charsetReader(r io.Reader) io.Reader {
+	br := bufio.NewReaderSize(r, sniffSize)
+	head, _ := br.Peek(sniffSize)
+
+	c := &charsetReader{r: br, unit: 1, buf: make([]byte, sniffSize)}
+	switch {
+	case bytes.HasPrefix(head, []byte{0xEF, 0xBB, 0xBF}):
+		br.Discard(3)
+		c.inPos = 3
+		d.charset, c.decode = CharsetUTF8, decodeUTF8
+	case bytes.HasPrefix(head, []byte{0xFF, 0xFE}):
+		br.Discard(2)
+		c.inPos = 2
+		d.charset, c.decode = CharsetUTF16LE, decodeUTF16LE
+	case bytes.HasPrefix(head, []byte{0xFE, 0xFF}):
+		br.Discard(2)
+		c.inPos = 2
+		d.charset, c.decode = CharsetUTF16BE, decodeUTF16BE
+	case len(head) >= 2 && head[0] != 0 && head[1] == 0:
+		d.charset, c.decode = CharsetUTF16LE, decodeUTF16LE
+	case len(head) >= 2 && head[0] == 0 && head[1] != 0:
+		d.charset, c.decode = CharsetUTF16BE, decodeUTF16BE
+	default:
+		d.charset, c.decode = charsetByName(headChar(head))
+		if c.decode == nil {
+			d.charset, c.decode = CharsetUTF8, decodeUTF8
+		}
+	}
+	if d.charset == CharsetUTF16LE || d.charset == CharsetUTF16BE {
+		c.unit, c.bigEndian = 2, d.charset == CharsetUTF16BE
+	}
+
+	c.eol.in = c.inPos // offsets in the input count the byte order mark
+	return c
+}
+
+// charsetByName returns the name of and a decoder for the character set
+// named by a HEAD.CHAR value. The decoder is nil if the name is not known.
+func charsetByName(value string) (string, charsetDecoder) {
+	switch strings.ToUpper(strings.TrimSpace(value)) {
+	case "ANSEL":
+		return CharsetANSEL, decodeANSEL
+	case "ANSI", "WINDOWS", "IBM WINDOWS", "CP1252", "WINDOWS-1252":
+		return CharsetANSI, decodeTable(&cp1252Runes)
+	case "MACINTOSH", "MACROMAN":
+		return CharsetMacintosh, decodeTable(&macRomanRunes)
+	case "IBMPC", "IBM DOS", "CP437":
+		return CharsetIBMPC, decodeTable(&cp437Runes)
+	case "ASCII":
+		return CharsetASCII, decodeASCII
+	case "", "UTF-8", "UTF8", "UNICODE", "UTF-16":
+		return CharsetUTF8, decodeUTF8
+	}
+	return "", nil
+}
+
+// headChar returns the value of the HEAD.CHAR line in data, which must be
+// in an ASCII compatible character set
+func headChar(data []byte) string {
+	lines := bytes.FieldsFunc(data, func(r rune) bool { return r == '\n' || r == '\r' })
+	for i, line := range lines {
+		fields := strings.Fields(string(line))
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "0" && i > 0 {
+			break
+		}
+		if len(fields) >= 2 && fields[0] == "1" && fields[1] == "CHAR" {
+			return strings.Join(fields[2:], " ")
+		}
+	}
+	return ""
+}
+
+// decodeUTF8 passes UTF-8 through unchanged. Bytes which are not valid
+// UTF-8 are taken to be Windows code page 1252, which is the usual
+// mistake in files that claim to be UTF-8 or ASCII.
+func decodeUTF8(dst []byte, src []byte, atEOF bool) ([]byte, int) {
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		if c < utf8.RuneSelf {
+			dst = append(dst, c)
+			i++
+			continue
+		}
+		if !atEOF && !utf8.FullRune(src[i:]) {
+			break
+		}
+		r, size := utf8.DecodeRune(src[i:])
+		if r == utf8.RuneError && size == 1 {
+			dst = appendRune(dst, cp1252Runes[c-0x80])
+		} else {
+			dst = append(dst, src[i:i+size]...)
+		}
+		i += size
+	}
+	return dst, i
+}
+
+// escapeSize is the length of the longest escape like @#U+10FFFF@
+const escapeSize = len("@#U+10FFFF@")
+
+// decodeASCII converts ASCII to UTF-8 as decodeUTF8 does, replacing each
+// escape like @#U+00E9@ written by an Encoder with its character
+func decodeASCII(dst []byte, src []byte, atEOF bool) ([]byte, int) {
+	i := 0
+	for i < len(src) {
+		j := bytes.IndexByte(src[i:], '@')
+		if j < 0 {
+			j = len(src)
+		} else {
+			j += i
+		}
+		var n int
+		dst, n = decodeUTF8(dst, src[i:j], atEOF || j < len(src))
+		if i += n; i < j || j == len(src) {
+			break
+		}
+
+		// src[i] is an @
+		switch {
+		case i+1 < len(src) && src[i+1] == '@':
+			dst = append(dst, "@@"...)
+			i += 2
+			continue
+		case !atEOF && len(src)-i < escapeSize && bytes.IndexByte(src[i+1:], '@') < 0:
+			return dst, i // the escape may be incomplete
+		}
+		if r, size := unicodeEscape(src[i:]); size > 0 {
+			dst = appendRune(dst, r)
+			i += size
+			continue
+		}
+		dst = append(dst, '@')
+		i++
+	}
+	return dst, i
+}
+
+// unicodeEscape returns the character of an escape like @#U+00E9@ at the
+// start of p and the length of the escape, or 0 if there is none there
+func unicodeEscape(p []byte) (rune, int) {
+	if !bytes.HasPrefix(p, []byte("@#U+")) {
+		return 0, 0
+	}
+	end := bytes.IndexByte(p[1:], '@') + 1
+	if end < 8 || end > escapeSize-1 {
+		return 0, 0
+	}
+	v, err := strconv.ParseUint(string(p[4:end]), 16, 32)
+	if err != nil || !utf8.ValidRune(rune(v)) {
+		return 0, 0
+	}
+	return rune(v), end + 1
+}
+
+// decodeUTF16LE converts little endian UTF-16 to UTF-8
+func decodeUTF16LE(dst []byte, src []byte, atEOF bool) ([]byte, int) {
+	return decodeUTF16(dst, src, atEOF, func(b []byte) uint16 { return uint16(b[0]) | uint16(b[1])<<8 })
+}
+
+// decodeUTF16BE converts big endian UTF-16 to UTF-8
+func decodeUTF16BE(dst []byte, src []byte, atEOF bool) ([]byte, int) {
+	return decodeUTF16(dst, src, atEOF, func(b []byte) uint16 { return uint16(b[0])<<8 | uint16(b[1]) })
+}
+
+// decodeUTF16 converts UTF-16 to UTF-8 using unit to read each code unit
+func decodeUTF16(dst []byte, src []byte, atEOF bool, unit func([]byte) uint16) ([]byte, int) {
+	i := 0
+	for i+1 < len(src) {
+		u := rune(unit(src[i:]))
+		if utf16.IsSurrogate(u) {
+			if i+3 >= len(src) {
+				if !atEOF {
+					break
+				}
+				dst = appendRune(dst, utf8.RuneError)
+				i += 2
+				continue
+			}
+			r := utf16.DecodeRune(u, rune(unit(src[i+2:])))
+			if r == utf8.RuneError {
+				dst = appendRune(dst, r)
+				i += 2
+				continue
+			}
+			dst = appendRune(dst, r)
+			i += 4
+			continue
+		}
+		dst = appendRune(dst, u)
+		i += 2
+	}
+	if atEOF && i < len(src) {
+		dst = appendRune(dst, utf8.RuneError)
+		i = len(src)
+	}
+	return dst, i
+}
+
+// decodeTable returns a decoder for a single byte character set which
+// is ASCII below 0x80 and given by table above
+func decodeTable(table *[128]rune) charsetDecoder {
+	return func(dst []byte, src []byte, atEOF bool) ([]byte, int) {
+		for _, c := range src {
+			if c < 0x80 {
+				dst = append(dst, c)
+			} else {
+				dst = appendRune(dst, table[c-0x80])
+			}
+		}
+		return dst, len(src)
+	}
+}
+
+// decodeANSEL converts ANSEL to UTF-8, moving each combining diacritic
+// after the character it modifies and composing them where Unicode has
+// a precomposed character
+func decodeANSEL(dst []byte, src []byte, atEOF bool) ([]byte, int) {
+	i := 0
+	for i < len(src) {
+		// collect the diacritics preceding a character
+		j := i
+		for j < len(src) && isAnselCombining(src[j]) {
+			j++
+		}
+		if j == len(src) {
+			if !atEOF {
+				break
+			}
+			for _, c := range src[i:j] {
+				dst = appendRune(dst, anselRunes[c-0x80])
+			}
+			i = j
+			break
+		}
+
+		base := anselRune(src[j])
+		var rest []rune
+		for _, c := range src[i:j] {
+			mark := anselRunes[c-0x80]
+			if r, found := anselCompose[[2]rune{base, mark}]; found {
+				base = r
+			} else {
+				rest = append(rest, mark)
+			}
+		}
+		dst = appendRune(dst, base)
+		for _, r := range rest {
+			dst = appendRune(dst, r)
+		}
+		i = j + 1
+	}
+	return dst, i
+}
+
+// anselRune returns the rune for an ANSEL byte which is not a diacritic
+func anselRune(c byte) rune {
+	if c < 0x80 {
+		return rune(c)
+	}
+	if r := anselRunes[c-0x80]; r != 0 {
+		return r
+	}
+	return utf8.RuneError
+}
+
+// appendRune appends the UTF-8 encoding of r to p
+func appendRune(p []byte, r rune) []byte {
+	var b [utf8.UTFMax]byte
+	n := utf8.EncodeRune(b[:], r)
+	return append(p, b[:n]...)
+}
+
+// cp1252Runes maps Windows code page 1252 bytes 0x80 to 0xFF to runes
+var cp1252Runes = [128]rune{
+	0x20AC, 0x0081, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021, // 0x80
+	0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0x008D, 0x017D, 0x008F, // 0x88
+	0x0090, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014, // 0x90
+	0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0x009D, 0x017E, 0x0178, // 0x98
+	0x00A0, 0x00A1, 0x00A2, 0x00A3, 0x00A4, 0x00A5, 0x00A6, 0x00A7, // 0xA0
+	0x00A8, 0x00A9, 0x00AA, 0x00AB, 0x00AC, 0x00AD, 0x00AE, 0x00AF, // 0xA8
+	0x00B0, 0x00B1, 0x00B2, 0x00B3, 0x00B4, 0x00B5, 0x00B6, 0x00B7, // 0xB0
+	0x00B8, 0x00B9, 0x00BA, 0x00BB, 0x00BC, 0x00BD, 0x00BE, 0x00BF, // 0xB8
+	0x00C0, 0x00C1, 0x00C2, 0x00C3, 0x00C4, 0x00C5, 0x00C6, 0x00C7, // 0xC0
+	0x00C8, 0x00C9, 0x00CA, 0x00CB, 0x00CC, 0x00CD, 0x00CE, 0x00CF, // 0xC8
+	0x00D0, 0x00D1, 0x00D2, 0x00D3, 0x00D4, 0x00D5, 0x00D6, 0x00D7, // 0xD0
+	0x00D8, 0x00D9, 0x00DA, 0x00DB, 0x00DC, 0x00DD, 0x00DE, 0x00DF, // 0xD8
+	0x00E0, 0x00E1, 0x00E2, 0x00E3, 0x00E4, 0x00E5, 0x00E6, 0x00E7, // 0xE0
+	0x00E8, 0x00E9, 0x00EA, 0x00EB, 0x00EC, 0x00ED, 0x00EE, 0x00EF, // 0xE8
+	0x00F0, 0x00F1, 0x00F2, 0x00F3, 0x00F4, 0x00F5, 0x00F6, 0x00F7, // 0xF0
+	0x00F8, 0x00F9, 0x00FA, 0x00FB, 0x00FC, 0x00FD, 0x00FE, 0x00FF, // 0xF8
+}
+
+// macRomanRunes maps Mac OS Roman bytes 0x80 to 0xFF to runes
+var macRomanRunes = [128]rune{
+	0x00C4, 0x00C5, 0x00C7, 0x00C9, 0x00D1, 0x00D6, 0x00DC, 0x00E1, // 0x80
+	0x00E0, 0x00E2, 0x00E4, 0x00E3, 0x00E5, 0x00E7, 0x00E9, 0x00E8, // 0x88
+	0x00EA, 0x00EB, 0x00ED, 0x00EC, 0x00EE, 0x00EF, 0x00F1, 0x00F3, // 0x90
+	0x00F2, 0x00F4, 0x00F6, 0x00F5, 0x00FA, 0x00F9, 0x00FB, 0x00FC, // 0x98
+	0x2020, 0x00B0, 0x00A2, 0x00A3, 0x00A7, 0x2022, 0x00B6, 0x00DF, // 0xA0
+	0x00AE, 0x00A9, 0x2122, 0x00B4, 0x00A8, 0x2260, 0x00C6, 0x00D8, // 0xA8
+	0x221E, 0x00B1, 0x2264, 0x2265, 0x00A5, 0x00B5, 0x2202, 0x2211, // 0xB0
+	0x220F, 0x03C0, 0x222B, 0x00AA, 0x00BA, 0x03A9, 0x00E6, 0x00F8, // 0xB8
+	0x00BF, 0x00A1, 0x00AC, 0x221A, 0x0192, 0x2248, 0x2206, 0x00AB, // 0xC0
+	0x00BB, 0x2026, 0x00A0, 0x00C0, 0x00C3, 0x00D5, 0x0152, 0x0153, // 0xC8
+	0x2013, 0x2014, 0x201C, 0x201D, 0x2018, 0x2019, 0x00F7, 0x25CA, // 0xD0
+	0x00FF, 0x0178, 0x2044, 0x20AC, 0x2039, 0x203A, 0xFB01, 0xFB02, // 0xD8
+	0x2021, 0x00B7, 0x201A, 0x201E, 0x2030, 0x00C2, 0x00CA, 0x00C1, // 0xE0
+	0x00CB, 0x00C8, 0x00CD, 0x00CE, 0x00CF, 0x00CC, 0x00D3, 0x00D4, // 0xE8
+	0xF8FF, 0x00D2, 0x00DA, 0x00DB, 0x00D9, 0x0131, 0x02C6, 0x02DC, // 0xF0
+	0x00AF, 0x02D8, 0x02D9, 0x02DA, 0x00B8, 0x02DD, 0x02DB, 0x02C7, // 0xF8
+}
+
+// cp437Runes maps IBM PC code page 437 bytes 0x80 to 0xFF to runes
+var cp437Runes = [128]rune{
+	0x00C7, 0x00FC, 0x00E9, 0x00E2, 0x00E4, 0x00E0, 0x00E5, 0x00E7, // 0x80
+	0x00EA, 0x00EB, 0x00E8, 0x00EF, 0x00EE, 0x00EC, 0x00C4, 0x00C5, // 0x88
+	0x00C9, 0x00E6, 0x00C6, 0x00F4, 0x00F6, 0x00F2, 0x00FB, 0x00F9, // 0x90
+	0x00FF, 0x00D6, 0x00DC, 0x00A2, 0x00A3, 0x00A5, 0x20A7, 0x0192, // 0x98
+	0x00E1, 0x00ED, 0x00F3, 0x00FA, 0x00F1, 0x00D1, 0x00AA, 0x00BA, // 0xA0
+	0x00BF, 0x2310, 0x00AC, 0x00BD, 0x00BC, 0x00A1, 0x00AB, 0x00BB, // 0xA8
+	0x2591, 0x2592, 0x2593, 0x2502, 0x2524, 0x2561, 0x2562, 0x2556, // 0xB0
+	0x2555, 0x2563, 0x2551, 0x2557, 0x255D, 0x255C, 0x255B, 0x2510, // 0xB8
+	0x2514, 0x2534, 0x252C, 0x251C, 0x2500, 0x253C, 0x255E, 0x255F, // 0xC0
+	0x255A, 0x2554, 0x2569, 0x2566, 0x2560, 0x2550, 0x256C, 0x2567, // 0xC8
+	0x2568, 0x2564, 0x2565, 0x2559, 0x2558, 0x2552, 0x2553, 0x256B, // 0xD0
+	0x256A, 0x2518, 0x250C, 0x2588, 0x2584, 0x258C, 0x2590, 0x2580, // 0xD8
+	0x03B1, 0x00DF, 0x0393, 0x03C0, 0x03A3, 0x03C3, 0x00B5, 0x03C4, // 0xE0
+	0x03A6, 0x0398, 0x03A9, 0x03B4, 0x221E, 0x03C6, 0x03B5, 0x2229, // 0xE8
+	0x2261, 0x00B1, 0x2265, 0x2264, 0x2320, 0x2321, 0x00F7, 0x2248, // 0xF0
+	0x00B0, 0x2219, 0x00B7, 0x221A, 0x207F, 0x00B2, 0x25A0, 0x00A0, // 0xF8
+}
@@ -0,0 +1,224 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+var charsetExamples = []struct {
+	charset string
+	char    string // HEAD.CHAR
+	bom     bool
+	extra   bool // the character set has characters outside Latin-1
+}{
+	{CharsetANSEL, "ANSEL", false, true},
+	{CharsetUTF8, "UTF-8", true, true},
+	{CharsetUTF16LE, "UNICODE", true, true},
+	{CharsetUTF16BE, "UNICODE", true, true},
+	{CharsetANSI, "IBM WINDOWS", false, false},
+	{CharsetMacintosh, "MACINTOSH", false, false},
+}
+
+// charsetNotes are added to the test files, which are ASCII
+const (
+	charsetNote      = "Zoë Brontë of Ærøskøbing, José Núñez"
+	charsetNoteExtra = "Łukasz Wałęsa, Dvořák, Œuvre, Ångström ǖ"
+)
+
+// charsetText returns the text of a test file with HEAD.CHAR set to char
+// and a note with non-ASCII characters added to its first individual,
+// followed by line if it is not empty
+func charsetText(t *testing.T, file, char string, extra bool, line string) string {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := string(data)
+
+	i := strings.Index(text, "\n1 CHAR ") + len("\n1 CHAR ")
+	text = text[:i] + char + text[i+strings.IndexByte(text[i:], '\n'):]
+
+	note := "1 NOTE " + charsetNote + "\n"
+	if extra {
+		note += "2 CONT " + charsetNoteExtra + "\n"
+	}
+	note += line
+	i = strings.Index(text, " INDI\n") + len(" INDI\n")
+	return text[:i] + note + text[i:]
+}
+
+// encodeCharset returns text as it is written in charset
+func encodeCharset(t *testing.T, text, charset string, bom bool) []byte {
+	var table *[128]rune
+	switch charset {
+	case CharsetUTF8:
+		if bom {
+			return append([]byte{0xEF, 0xBB, 0xBF}, text...)
+		}
+		return []byte(text)
+	case CharsetUTF16LE, CharsetUTF16BE:
+		p := appendUTF16(nil, 0xFEFF, charset == CharsetUTF16BE)
+		for _, r := range text {
+			p = appendUTF16(p, r, charset == CharsetUTF16BE)
+		}
+		return p
+	case CharsetANSI:
+		table = &cp1252Runes
+	case CharsetMacintosh:
+		table = &macRomanRunes
+	}
+
+	var p []byte
+	for _, r := range text {
+		if table == nil {
+			var ok bool
+			if p, ok = appendANSEL(p, r); !ok {
+				t.Fatalf("cannot encode %q in ANSEL", r)
+			}
+			continue
+		}
+		if r < utf8.RuneSelf {
+			p = append(p, byte(r))
+			continue
+		}
+		found := false
+		for i, tr := range table {
+			if tr == r {
+				p = append(p, byte(i+0x80))
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Fatalf("cannot encode %q in %s", r, charset)
+		}
+	}
+	return p
+}
+
+func TestCharsets(t *testing.T) {
+	for _, file := range []string{"testdata/allged.ged", "testdata/kennedy.ged"} {
+		for _, ex := range charsetExamples {
+			text := charsetText(t, file, "UTF-8", ex.extra, "")
+			want, err := NewDecoder(strings.NewReader(text)).Decode()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			text = charsetText(t, file, ex.char, ex.extra, "")
+			d := NewDecoder(bytes.NewReader(encodeCharset(t, text, ex.charset, ex.bom)))
+			g, err := d.Decode()
+			if err != nil {
+				t.Fatalf("%s in %s: Decode returned error %v, expected no error", file, ex.charset, err)
+			}
+			if d.charset != ex.charset {
+				t.Errorf("%s in %s: Decode detected %s", file, ex.charset, d.charset)
+			}
+
+			note := g.Individual[0].Note[0].Note
+			if ex.extra && note != charsetNote+"\n"+charsetNoteExtra || !ex.extra && note != charsetNote {
+				t.Errorf("%s in %s: note was %q", file, ex.charset, note)
+			}
+
+			g.Header.CharacterSet, want.Header.CharacterSet = nil, nil
+			if g.String() != want.String() {
+				t.Errorf("%s in %s: Decode did not match the UTF-8 decode", file, ex.charset)
+			}
+		}
+	}
+}
+
+func TestCharsetOffsets(t *testing.T) {
+	for _, file := range []string{"testdata/allged.ged", "testdata/kennedy.ged"} {
+		for _, ex := range charsetExamples {
+			text := charsetText(t, file, ex.char, ex.extra, "  X BAD\r\n")
+			data := encodeCharset(t, text, ex.charset, ex.bom)
+			offset := int64(len(encodeCharset(t, text[:strings.Index(text, "X BAD")], ex.charset, ex.bom)))
+
+			for _, opts := range []DecoderOptions{{}, {Workers: 4}} {
+				d := NewDecoderWithOptions(bytes.NewReader(data), opts)
+				if _, err := d.Decode(); err != nil {
+					t.Fatal(err)
+				}
+				found := false
+				for _, e := range d.Errors {
+					if e.Kind == ErrSyntax {
+						found = true
+						if e.Offset != offset {
+							t.Errorf("%s in %s with %+v: offset was %d, expected %d", file, ex.charset, opts, e.Offset, offset)
+						}
+					}
+				}
+				if !found {
+					t.Errorf("%s in %s with %+v: Decode reported no syntax error", file, ex.charset, opts)
+				}
+			}
+
+			_, err := ReadTree(bytes.NewReader(data))
+			if e, ok := err.(*DecodeError); !ok || e.Offset != offset {
+				t.Errorf("%s in %s: ReadTree returned %v, expected a syntax error at offset %d", file, ex.charset, err, offset)
+			}
+		}
+	}
+}
+
+func TestDecodeANSEL(t *testing.T) {
+	examples := []struct {
+		in   string
+		want string
+	}{
+		{"\xe2e", "é"},
+		{"\xe8\xe5u", "ǖ"},
+		{"\xf2\xe3a", "ậ"},
+		{"\xe9\xa2", "Ø̌"},
+		{"\xb1", "ł"},
+		{"x\xe2", "x́"},
+		{"\x80", "�"},
+	}
+	for _, ex := range examples {
+		got, n := decodeANSEL(nil, []byte(ex.in), true)
+		if string(got) != ex.want || n != len(ex.in) {
+			t.Errorf("decodeANSEL(%q) = %q, %d, expected %q, %d", ex.in, got, n, ex.want, len(ex.in))
+		}
+	}
+
+	// a diacritic at the end of the input waits for its character
+	got, n := decodeANSEL(nil, []byte("a\xe2"), false)
+	if string(got) != "a" || n != 1 {
+		t.Errorf("decodeANSEL left %q, %d, expected %q, %d", got, n, "a", 1)
+	}
+}
+
+func TestDecodeASCII(t *testing.T) {
+	examples := []struct {
+		in   string
+		want string
+	}{
+		{"Ren@#U+00E9@", "René"},
+		{"@#U+1F600@ and @#U+10FFFF@", "😀 and \U0010FFFF"},
+		{"a@@#U+00E9@", "a@@#U+00E9@"},
+		{"@I1@ @#DJULIAN@", "@I1@ @#DJULIAN@"},
+		{"@#U+E9@ @#U+D800@ @#U+00E9", "@#U+E9@ @#U+D800@ @#U+00E9"},
+		{"caf\xe9", "café"},
+	}
+	for _, ex := range examples {
+		got, n := decodeASCII(nil, []byte(ex.in), true)
+		if string(got) != ex.want || n != len(ex.in) {
+			t.Errorf("decodeASCII(%q) = %q, %d, expected %q, %d", ex.in, got, n, ex.want, len(ex.in))
+		}
+	}
+
+	// an escape at the end of the input waits for the rest of it
+	got, n := decodeASCII(nil, []byte("a@#U+00"), false)
+	if string(got) != "a" || n != 1 {
+		t.Errorf("decodeASCII left %q, %d, expected %q, %d", got, n, "a", 1)
+	}
+}
@@ -0,0 +1,446 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Calendar identifies the calendar of a date
+type Calendar int
+
+const (
+	Gregorian       Calendar = iota // @#DGREGORIAN@, the default
+	Julian                          // @#DJULIAN@
+	Hebrew                          // @#DHEBREW@
+	French                          // @#DFRENCH R@
+	Roman                           // @#DROMAN@
+	UnknownCalendar                 // @#DUNKNOWN@
+)
+
+var calendarEscapes = [...]string{
+	Gregorian:       "@#DGREGORIAN@",
+	Julian:          "@#DJULIAN@",
+	Hebrew:          "@#DHEBREW@",
+	French:          "@#DFRENCH R@",
+	Roman:           "@#DROMAN@",
+	UnknownCalendar: "@#DUNKNOWN@",
+}
+
+// String returns the date escape of the calendar
+func (c Calendar) String() string {
+	if c >= 0 && int(c) < len(calendarEscapes) {
+		return calendarEscapes[c]
+	}
+	return fmt.Sprintf("Calendar(%d)", int(c))
+}
+
+// months returns the month names of the calendar
+func (c Calendar) months() []string {
+	switch c {
+	case Hebrew:
+		return hebrewMonths
+	case French:
+		return frenchMonths
+	}
+	return gregorianMonths
+}
+
+var gregorianMonths = []string{
+	"JAN", "FEB", "MAR", "APR", "MAY", "JUN",
+	"JUL", "AUG", "SEP", "OCT", "NOV", "DEC",
+}
+
+var hebrewMonths = []string{
+	"TSH", "CSH", "KSL", "TVT", "SHV", "ADR", "ADS",
+	"NSN", "IYR", "SVN", "TMZ", "AAV", "ELL",
+}
+
+var frenchMonths = []string{
+	"VEND", "BRUM", "FRIM", "NIVO", "PLUV", "VENT", "GERM",
+	"FLOR", "PRAI", "MESS", "THER", "FRUC", "COMP",
+}
+
+// CalendarDate represents a date in a calendar; the day and month may be missing
+type CalendarDate struct {
+	Calendar Calendar // calendar escape
+	Day      int      // day of the month, or 0
+	Month    int      // month of the year from 1, or 0
+	Year     int      // year
+	DualYear int      // later year of a dual year like 1700/01, or 0
+	BC       bool     // year is B.C.
+}
+
+// IsZero reports whether the date is missing
+func (d CalendarDate) IsZero() bool {
+	return d == CalendarDate{}
+}
+
+// String formats the date as in GEDCOM; the calendar escape is omitted for
+// Gregorian dates
+func (d CalendarDate) String() string {
+	var ss []string
+
+	if d.Calendar != Gregorian {
+		ss = append(ss, d.Calendar.String())
+	}
+	if d.Day != 0 {
+		ss = append(ss, strconv.Itoa(d.Day))
+	}
+	if d.Month != 0 {
+		ss = append(ss, d.Calendar.months()[d.Month-1])
+	}
+	year := strconv.Itoa(d.Year)
+	if d.DualYear != 0 {
+		year += fmt.Sprintf("/%02d", d.DualYear%100)
+	}
+	ss = append(ss, year)
+	if d.BC {
+		ss = append(ss, "B.C.")
+	}
+
+	return strings.Join(ss, " ")
+}
+
+// DateQualifier represents the approximation of a date
+type DateQualifier int
+
+const (
+	QualifierNone        DateQualifier = iota // exact date
+	QualifierAbout                            // ABT
+	QualifierCalculated                       // CAL
+	QualifierEstimated                        // EST
+	QualifierInterpreted                      // INT, with a phrase
+)
+
+var qualifierKeywords = [...]string{
+	QualifierNone:        "",
+	QualifierAbout:       "ABT",
+	QualifierCalculated:  "CAL",
+	QualifierEstimated:   "EST",
+	QualifierInterpreted: "INT",
+}
+
+// String returns the GEDCOM keyword of the qualifier
+func (q DateQualifier) String() string {
+	if q >= 0 && int(q) < len(qualifierKeywords) {
+		return qualifierKeywords[q]
+	}
+	return fmt.Sprintf("DateQualifier(%d)", int(q))
+}
+
+// DateKind represents the form of a date value: a single date, a range,
+// a period or a phrase
+type DateKind int
+
+const (
+	DateSingle  DateKind = iota // date
+	DateBefore                  // BEF date
+	DateAfter                   // AFT date
+	DateBetween                 // BET date AND date
+	DateFrom                    // FROM date
+	DateTo                      // TO date
+	DateFromTo                  // FROM date TO date
+	DatePhrase                  // (phrase)
+)
+
+var dateKindNames = [...]string{
+	DateSingle:  "single",
+	DateBefore:  "before",
+	DateAfter:   "after",
+	DateBetween: "between",
+	DateFrom:    "from",
+	DateTo:      "to",
+	DateFromTo:  "from-to",
+	DatePhrase:  "phrase",
+}
+
+// String returns the name of the kind of date
+func (k DateKind) String() string {
+	if k >= 0 && int(k) < len(dateKindNames) {
+		return dateKindNames[k]
+	}
+	return fmt.Sprintf("DateKind(%d)", int(k))
+}
+
+// ParsedDate represents a GEDCOM date value
+type ParsedDate struct {
+	Qualifier DateQualifier // ABT, CAL, EST or INT
+	Kind      DateKind      // single date, range, period or phrase
+	Date1     CalendarDate  // the date, or the first date of BET and FROM..TO
+	Date2     CalendarDate  // the second date of BET and FROM..TO
+	Phrase    string        // text of a date phrase, without parentheses
+}
+
+// String formats the date value canonically
+func (p *ParsedDate) String() string {
+	switch p.Kind {
+	case DatePhrase:
+		return "(" + p.Phrase + ")"
+	case DateBefore:
+		return "BEF " + p.Date1.String()
+	case DateAfter:
+		return "AFT " + p.Date1.String()
+	case DateBetween:
+		return "BET " + p.Date1.String() + " AND " + p.Date2.String()
+	case DateFrom:
+		return "FROM " + p.Date1.String()
+	case DateTo:
+		return "TO " + p.Date1.String()
+	case DateFromTo:
+		return "FROM " + p.Date1.String() + " TO " + p.Date2.String()
+	}
+
+	s := p.Date1.String()
+	if p.Qualifier != QualifierNone {
+		s = p.Qualifier.String() + " " + s
+	}
+	if p.Qualifier == QualifierInterpreted && p.Phrase != "" {
+		s += " (" + p.Phrase + ")"
+	}
+	return s
+}
+
+// Parse parses the date value of the record
+func (r *DateRecord) Parse() (*ParsedDate, error) {
+	return ParseDate(r.Date)
+}
+
+// ParseDate parses a GEDCOM date value
+func ParseDate(value string) (*ParsedDate, error) {
+	p := &ParsedDate{}
+	s := strings.TrimSpace(value)
+
+	// a phrase is everything in parentheses
+	if i := strings.IndexByte(s, '('); i >= 0 {
+		j := strings.LastIndexByte(s, ')')
+		if j < i {
+			return nil, &DateError{Value: value, Msg: "unclosed date phrase"}
+		}
+		p.Phrase = s[i+1 : j]
+		if strings.TrimSpace(s[j+1:]) != "" {
+			return nil, &DateError{Value: value, Msg: "text after date phrase"}
+		}
+		s = strings.TrimSpace(s[:i])
+		if s == "" {
+			p.Kind = DatePhrase
+			return p, nil
+		}
+	}
+
+	tokens := dateTokens(s)
+	if len(tokens) == 0 {
+		return nil, &DateError{Value: value, Msg: "empty date"}
+	}
+
+	var err error
+	keyword := strings.ToUpper(tokens[0])
+	rest := tokens[1:]
+	switch keyword {
+	case "ABT", "CAL", "EST", "INT":
+		for q, k := range qualifierKeywords {
+			if k == keyword {
+				p.Qualifier = DateQualifier(q)
+			}
+		}
+		p.Date1, err = parseCalendarDate(rest)
+
+	case "BEF", "AFT", "TO":
+		p.Kind = map[string]DateKind{"BEF": DateBefore, "AFT": DateAfter, "TO": DateTo}[keyword]
+		p.Date1, err = parseCalendarDate(rest)
+
+	case "BET":
+		i := indexToken(rest, "AND")
+		if i < 0 {
+			return nil, &DateError{Value: value, Msg: "BET without AND"}
+		}
+		p.Kind = DateBetween
+		p.Date1, err = parseCalendarDate(rest[:i])
+		if err == nil {
+			p.Date2, err = parseCalendarDate(rest[i+1:])
+		}
+
+	case "FROM":
+		p.Kind = DateFrom
+		i := indexToken(rest, "TO")
+		if i < 0 {
+			p.Date1, err = parseCalendarDate(rest)
+			break
+		}
+		p.Kind = DateFromTo
+		p.Date1, err = parseCalendarDate(rest[:i])
+		if err == nil {
+			p.Date2, err = parseCalendarDate(rest[i+1:])
+		}
+
+	default:
+		p.Date1, err = parseCalendarDate(tokens)
+	}
+	if err != nil {
+		return nil, &DateError{Value: value, Msg: err.Error()}
+	}
+
+	if p.Phrase != "" && p.Qualifier != QualifierInterpreted {
+		return nil, &DateError{Value: value, Msg: "date phrase without INT"}
+	}
+
+	return p, nil
+}
+
+// dateTokens splits a date value into words, keeping "@#DFRENCH R@" whole
+func dateTokens(s string) []string {
+	var tokens []string
+	for _, f := range strings.Fields(s) {
+		n := len(tokens)
+		if n > 0 && strings.HasPrefix(tokens[n-1], "@#") && !strings.HasSuffix(tokens[n-1], "@") {
+			tokens[n-1] += " " + f
+			continue
+		}
+		tokens = append(tokens, f)
+	}
+	return tokens
+}
+
+// indexToken returns the index of the keyword in tokens, or -1
+func indexToken(tokens []string, keyword string) int {
+	for i, t := range tokens {
+		if strings.ToUpper(t) == keyword {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseCalendarDate parses [calendar escape] [[day] month] year [B.C.]
+func parseCalendarDate(tokens []string) (CalendarDate, error) {
+	var d CalendarDate
+
+	if len(tokens) > 0 && strings.HasPrefix(tokens[0], "@#") {
+		escape := strings.ToUpper(tokens[0])
+		found := false
+		for c, e := range calendarEscapes {
+			if e == escape {
+				d.Calendar = Calendar(c)
+				found = true
+			}
+		}
+		if !found {
+			return d, fmt.Errorf("unknown calendar %s", tokens[0])
+		}
+		tokens = tokens[1:]
+	}
+
+	if n := len(tokens); n > 0 {
+		switch strings.ToUpper(tokens[n-1]) {
+		case "B.C.", "BC", "(B.C.)":
+			d.BC = true
+			tokens = tokens[:n-1]
+		}
+	}
+
+	switch len(tokens) {
+	case 0:
+		return d, fmt.Errorf("missing year")
+	case 1, 2, 3:
+	default:
+		return d, fmt.Errorf("too many words in %q", strings.Join(tokens, " "))
+	}
+
+	if err := d.parseYear(tokens[len(tokens)-1]); err != nil {
+		return d, err
+	}
+
+	if len(tokens) >= 2 {
+		month := strings.ToUpper(tokens[len(tokens)-2])
+		for i, m := range d.Calendar.months() {
+			if m == month {
+				d.Month = i + 1
+			}
+		}
+		if d.Month == 0 {
+			return d, fmt.Errorf("unknown month %s", tokens[len(tokens)-2])
+		}
+	}
+
+	if len(tokens) == 3 {
+		day, err := strconv.Atoi(tokens[0])
+		if err != nil || day < 1 || day > d.daysInMonth() {
+			return d, fmt.Errorf("invalid day %s", tokens[0])
+		}
+		d.Day = day
+	}
+
+	return d, nil
+}
+
+// parseYear parses a year or a dual year like 1700/01
+func (d *CalendarDate) parseYear(s string) error {
+	year, dual := s, ""
+	if i := strings.IndexByte(s, '/'); i >= 0 {
+		year, dual = s[:i], s[i+1:]
+	}
+
+	y, err := strconv.Atoi(year)
+	if err != nil || y < 1 {
+		return fmt.Errorf("invalid year %s", s)
+	}
+	d.Year = y
+
+	if dual != "" {
+		if d.Calendar != Gregorian && d.Calendar != Julian {
+			return fmt.Errorf("dual year %s not in Gregorian or Julian calendar", s)
+		}
+		yy, err := strconv.Atoi(dual)
+		if err != nil || len(dual) > 2 && yy != y+1 {
+			return fmt.Errorf("invalid dual year %s", s)
+		}
+		if len(dual) <= 2 {
+			yy += y - y%100
+			if yy <= y {
+				yy += 100
+			}
+		}
+		if yy != y+1 {
+			return fmt.Errorf("invalid dual year %s", s)
+		}
+		d.DualYear = yy
+	}
+
+	return nil
+}
+
+// daysInMonth returns the greatest day allowed in the month of d
+func (d CalendarDate) daysInMonth() int {
+	switch d.Calendar {
+	case Hebrew:
+		return hebrewMonthDays(d.Year, hebrewMonth(d.Year, d.Month))
+	case French:
+		if d.Month == 13 {
+			return 6
+		}
+		return 30
+	}
+
+	switch d.Month {
+	case 4, 6, 9, 11:
+		return 30
+	case 2:
+		year := d.Year
+		if d.DualYear != 0 {
+			year = d.DualYear
+		}
+		if d.BC {
+			year = 1 - year // astronomical year numbering, as Bounds uses
+		}
+		if floorMod(year, 4) == 0 && (d.Calendar == Julian || floorMod(year, 100) != 0 || floorMod(year, 400) == 0) {
+			return 29
+		}
+		return 28
+	}
+	return 31
+}
@@ -0,0 +1,102 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+var dateExamples = []struct {
+	input string
+	want  ParsedDate
+	str   string
+}{
+	{"1850", ParsedDate{Date1: CalendarDate{Year: 1850}}, "1850"},
+	{"ABT 1850", ParsedDate{Qualifier: QualifierAbout, Date1: CalendarDate{Year: 1850}}, "ABT 1850"},
+	{"cal mar 1850", ParsedDate{Qualifier: QualifierCalculated, Date1: CalendarDate{Month: 3, Year: 1850}}, "CAL MAR 1850"},
+	{"EST 29 FEB 1904", ParsedDate{Qualifier: QualifierEstimated, Date1: CalendarDate{Day: 29, Month: 2, Year: 1904}}, "EST 29 FEB 1904"},
+	{"BEF 1 JAN 1900", ParsedDate{Kind: DateBefore, Date1: CalendarDate{Day: 1, Month: 1, Year: 1900}}, "BEF 1 JAN 1900"},
+	{"AFT 1900", ParsedDate{Kind: DateAfter, Date1: CalendarDate{Year: 1900}}, "AFT 1900"},
+	{"BET 1 JAN 1900 AND 1905", ParsedDate{Kind: DateBetween, Date1: CalendarDate{Day: 1, Month: 1, Year: 1900}, Date2: CalendarDate{Year: 1905}}, "BET 1 JAN 1900 AND 1905"},
+	{"FROM 1900", ParsedDate{Kind: DateFrom, Date1: CalendarDate{Year: 1900}}, "FROM 1900"},
+	{"TO 1900", ParsedDate{Kind: DateTo, Date1: CalendarDate{Year: 1900}}, "TO 1900"},
+	{"FROM  JUN 1900  TO 1910", ParsedDate{Kind: DateFromTo, Date1: CalendarDate{Month: 6, Year: 1900}, Date2: CalendarDate{Year: 1910}}, "FROM JUN 1900 TO 1910"},
+	{"@#DJULIAN@ 1 MAR 1700/01", ParsedDate{Date1: CalendarDate{Calendar: Julian, Day: 1, Month: 3, Year: 1700, DualYear: 1701}}, "@#DJULIAN@ 1 MAR 1700/01"},
+	{"@#DJULIAN@ 29 FEB 1699/00", ParsedDate{Date1: CalendarDate{Calendar: Julian, Day: 29, Month: 2, Year: 1699, DualYear: 1700}}, ""},
+	{"@#DGREGORIAN@ 2 SEP 1752", ParsedDate{Date1: CalendarDate{Day: 2, Month: 9, Year: 1752}}, "2 SEP 1752"},
+	{"@#DHEBREW@ 15 NSN 5600", ParsedDate{Date1: CalendarDate{Calendar: Hebrew, Day: 15, Month: 8, Year: 5600}}, "@#DHEBREW@ 15 NSN 5600"},
+	{"@#DFRENCH R@ 1 VEND 1", ParsedDate{Date1: CalendarDate{Calendar: French, Day: 1, Month: 1, Year: 1}}, "@#DFRENCH R@ 1 VEND 1"},
+	{"44 B.C.", ParsedDate{Date1: CalendarDate{Year: 44, BC: true}}, "44 B.C."},
+	{"29 FEB 5 B.C.", ParsedDate{Date1: CalendarDate{Day: 29, Month: 2, Year: 5, BC: true}}, ""},
+	{"@#DJULIAN@ 29 FEB 101 B.C.", ParsedDate{Date1: CalendarDate{Calendar: Julian, Day: 29, Month: 2, Year: 101, BC: true}}, ""},
+	{"29 FEB 401 B.C.", ParsedDate{Date1: CalendarDate{Day: 29, Month: 2, Year: 401, BC: true}}, ""},
+	{"@#DHEBREW@ 30 ADR 5600", ParsedDate{Date1: CalendarDate{Calendar: Hebrew, Day: 30, Month: 6, Year: 5600}}, ""},
+	{"@#DHEBREW@ 30 CSH 5600", ParsedDate{Date1: CalendarDate{Calendar: Hebrew, Day: 30, Month: 2, Year: 5600}}, ""},
+	{"INT 1900 (circa)", ParsedDate{Qualifier: QualifierInterpreted, Date1: CalendarDate{Year: 1900}, Phrase: "circa"}, "INT 1900 (circa)"},
+	{"(Easter, the year the war ended)", ParsedDate{Kind: DatePhrase, Phrase: "Easter, the year the war ended"}, ""},
+}
+
+func TestParseDate(t *testing.T) {
+	for _, ex := range dateExamples {
+		p, err := ParseDate(ex.input)
+		if err != nil {
+			t.Errorf("ParseDate(%q) returned error %v, expected no error", ex.input, err)
+			continue
+		}
+		if diff := deep.Equal(*p, ex.want); diff != nil {
+			t.Errorf("ParseDate(%q): %v", ex.input, diff)
+		}
+		str := ex.str
+		if str == "" {
+			str = ex.input
+		}
+		if p.String() != str {
+			t.Errorf("ParseDate(%q).String() = %q, expected %q", ex.input, p.String(), str)
+		}
+	}
+}
+
+var badDates = []string{
+	"",
+	"1 JAN",
+	"32 JAN 1900",
+	"29 FEB 1900",
+	"29 FEB 1699/00",
+	"1 FOO 1900",
+	"BET 1900",
+	"ABT 1900 (circa)",
+	"1900 (unclosed",
+	"29 FEB 2 B.C.",
+	"29 FEB 101 B.C.",
+	"0",
+	"1 JAN 0 B.C.",
+	"@#DHEBREW@ 1 JAN 5600",
+	"@#DHEBREW@ 30 TVT 5600",
+	"@#DHEBREW@ 30 ADR 5601",
+	"@#DHEBREW@ 30 IYR 5600",
+	"@#DHEBREW@ 30 CSH 5601",
+	"@#DHEBREW@ 5600/01",
+	"1700/05",
+	"@#DMARTIAN@ 1900",
+	"1 2 3 1900",
+}
+
+func TestParseDateErrors(t *testing.T) {
+	for _, input := range badDates {
+		if p, err := ParseDate(input); err == nil {
+			t.Errorf("ParseDate(%q) = %v, expected an error", input, p)
+		} else if _, ok := err.(*DateError); !ok {
+			t.Errorf("ParseDate(%q) returned %T, expected *DateError", input, err)
+		}
+	}
+
+	r := &DateRecord{Date: "ABT 1850"}
+	if p, err := r.Parse(); err != nil || p.Qualifier != QualifierAbout {
+		t.Errorf("DateRecord.Parse() = %v, %v, expected ABT 1850", p, err)
+	}
+}
@@ -7,19 +7,99 @@ information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
 package gedcom
 
 import (
+	"context"
 	"fmt"
 	"io"
-	"log"
 	"strconv"
+	"strings"
 )
 
 // A Decoder reads and decodes GEDCOM objects from an input stream.
 type Decoder struct {
-	r            io.Reader
-	parsers      []parser
-	refs         map[string]interface{}
-	LineNum      int
-	warningCount int
+	r         io.Reader
+	parsers   []parser
+	refs      map[string]interface{}
+	LineNum   int
+	Errors    []*DecodeError // problems found while decoding
+	opts      DecoderOptions
+	offset    int64         // byte offset of the current line
+	xref      string        // xref_id of the current level 0 record
+	prevLevel int           // level of the previous line
+	charset   string        // character set of the input
+	abort     error         // error which stops decoding
+	stream    *decodeStream // state of Next
+	input     *countingReader
+	records   int        // level 0 records completed
+	known     []int      // known lines at each level under the current line above
+	captured  bool       // the current line was kept as an unknown line
+	xrefs     *xrefTable // xref sites read, if tracked
+}
+
+// maxKnownLevel limits the levels at which known lines are counted
+const maxKnownLevel = 100
+
+// Mode selects how the Decoder treats problems in the input
+type Mode int
+
+const (
+	Lenient Mode = iota // record problems and keep going
+	Strict              // stop at the first problem
+	Recover             // record problems and repair level jumps
+)
+
+// DecoderOptions controls the behaviour of a Decoder
+type DecoderOptions struct {
+	Mode Mode
+
+	// OnDiagnostic, if not nil, is called with each problem as it is found.
+	// Returning an error stops decoding and Decode returns that error.
+	OnDiagnostic func(Diagnostic) error
+
+	// FillNames sets the empty NPFX, GIVN, SPFX, SURN and NSFX values of
+	// each NAME from the pieces of the NAME value; see NameRecord.Fill.
+	// Write then writes the values it sets.
+	FillNames bool
+
+	// KeepXrefs keeps the xrefs defined and pointed to by the records
+	// returned by Next, for Decoder.Integrity.
+	KeepXrefs bool
+
+	// OnProgress, if not nil, is called after each level 0 record is
+	// decoded and at the end of the input.
+	OnProgress func(Progress)
+
+	// Workers, if more than 1, is the number of goroutines which parse
+	// level 0 records for Decode, while the input is read. The result is
+	// the same as with one. It is not used in Strict mode or with an
+	// OnDiagnostic handler, which must see problems in order.
+	Workers int
+}
+
+// Progress reports how far a Decoder has got through its input
+type Progress struct {
+	Bytes   int64 // bytes read from the input, before conversion to UTF-8
+	Lines   int   // lines read
+	Records int   // level 0 records completed
+}
+
+// countingReader counts the bytes read from a Reader
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read reads from the underlying Reader and counts the bytes
+func (c *countingReader) Read(p []byte) (n int, err error) {
+	n, err = c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// progress passes the progress so far to the progress handler
+func (d *Decoder) progress() {
+	if d.opts.OnProgress != nil {
+		d.opts.OnProgress(Progress{Bytes: d.input.n, Lines: d.LineNum, Records: d.records})
+	}
 }
 
 // NewDecoder returns a new decoder that reads from r.
@@ -27,17 +107,31 @@ func NewDecoder(r io.Reader) *Decoder {
 	return &Decoder{r: r}
 }
 
-// Count warnings
-func (d *Decoder) CountWarnings() {
-	d.warningCount += 1
-	if d.warningCount >= 10 {
-		panic("too many warnings.")
+// NewDecoderWithOptions returns a new decoder that reads from r using opts.
+func NewDecoderWithOptions(r io.Reader, opts DecoderOptions) *Decoder {
+	return &Decoder{r: r, opts: opts}
+}
+
+// CountWarnings returns the number of warnings recorded so far
+func (d *Decoder) CountWarnings() int {
+	n := 0
+	for _, e := range d.Errors {
+		if e.Severity == SeverityWarning {
+			n++
+		}
 	}
+	return n
 }
 
 // Decode reads the next GEDCOM-encoded value from its
 // input and stores it in the value pointed to by v.
 func (d *Decoder) Decode() (*RootRecord, error) {
+	return d.DecodeContext(context.Background())
+}
+
+// DecodeContext is like Decode, but stops between lines when ctx is done,
+// returning what has been decoded so far and the error from ctx
+func (d *Decoder) DecodeContext(ctx context.Context) (*RootRecord, error) {
 
 	r := &RootRecord{
 		Level:            -1,
@@ -60,72 +154,195 @@ func (d *Decoder) Decode() (*RootRecord, error) {
 
 	d.refs = make(map[string]interface{})
 	d.parsers = []parser{makeRootParser(d, r)}
-	err := d.scan(r)
-	if err != nil {
-		log.Println(err.Error())
+	d.prevLevel = -1
+	d.input = &countingReader{r: d.r}
+	d.r = d.charsetReader(d.input)
+	var err error
+	if d.opts.Workers > 1 && d.opts.Mode != Strict && d.opts.OnDiagnostic == nil {
+		err = d.scanParallel(ctx, r)
+	} else {
+		err = d.scan(ctx, func(l line) error {
+			return d.parseLine(l, true)
+		})
+	}
+	r.xrefs = d.xrefs
+
+	r.Resolve()
+
+	if d.opts.FillNames {
+		for _, indi := range r.Individual {
+			for _, name := range indi.Name {
+				name.Fill()
+			}
+		}
 	}
 
 	return r, err
 }
 
-func (d *Decoder) scan(r *RootRecord) (err error) {
-	s := &scanner{}
-	buf := make([]byte, 512)
+// report records a problem found at the current line and passes it to the
+// diagnostic handler. Decoding stops after a fatal problem, after any
+// problem in Strict mode, or when the handler returns an error.
+func (d *Decoder) report(kind ErrorKind, severity Severity, msg string, level int, tag string, value string, err error) *DecodeError {
+	if d.opts.Mode == Strict {
+		severity = SeverityFatal
+	}
+	e := &DecodeError{
+		Line:     d.LineNum,
+		Offset:   d.offset,
+		Level:    level,
+		Tag:      tag,
+		Value:    value,
+		Xref:     d.xref,
+		Kind:     kind,
+		Severity: severity,
+		Msg:      msg,
+		Err:      err,
+	}
+	d.Errors = append(d.Errors, e)
 
-	var n int
-	n, err = d.r.Read(buf)
-	if err != nil {
-		log.Fatalf("Fatal: at %d: %s\n", d.LineNum, err.Error())
-		return
+	if d.opts.OnDiagnostic != nil {
+		if herr := d.opts.OnDiagnostic(*e); herr != nil && d.abort == nil {
+			d.abort = herr
+		}
 	}
+	if severity == SeverityFatal && d.abort == nil {
+		d.abort = e
+	}
+	return e
+}
 
-	for n > 0 {
-		pos := 0
+// unhandled records a tag which is not understood by the parser for context
+func (d *Decoder) unhandled(context string, level int, tag string, value string) {
+	d.report(ErrUnhandledTag, SeverityWarning, "unhandled "+context+" tag", level, tag, value, nil)
+}
 
-		for {
-			s.reset()
-			d.LineNum += 1
-			var offset int
-			offset, err = s.nextTag(buf[pos:n])
-			pos += offset
-			if err != nil {
-				if err == io.EOF {
-					err = nil
-				} else {
-					log.Printf("Error: at %d: %s\n", d.LineNum, err.Error())
-				}
-				break
-			}
+// unknown records a tag which is not understood by the parser for context
+// and keeps it, with its subordinate lines, in r
+func (d *Decoder) unknown(context string, r *RawLines, level int, tag string, value string, xref string) {
+	d.unhandled(context, level, tag, value)
+	d.capture(r, level, tag, value, xref)
+}
 
-			var xref string
-			if s.xref != nil && len(s.xref) > 0 {
-				xref = "@" + string(s.xref) + "@"
-			} else {
-				xref = ""
+// capture keeps a line, with its subordinate lines, in r
+func (d *Decoder) capture(r *RawLines, level int, tag string, value string, xref string) {
+	rec := &RawLine{Level: level, Xref: xref, Tag: tag, Value: value}
+	if level < len(d.known) {
+		rec.Position = d.known[level] + 1
+	}
+	d.captured = true
+	*r = append(*r, rec)
+	d.pushParser(makeRawParser(d, r, level))
+}
+
+// line is a line scanned by nextLine
+type line struct {
+	level int
+	xref  string
+	tag   string
+	value string
+}
+
+// nextLine scans the next well formed line, reporting problems with the
+// lines before it. It returns io.EOF at the end of the input, or the error
+// which stops decoding.
+func (d *Decoder) nextLine(lr *lineReader) (l line, err error) {
+	s := &lr.s
+
+	for {
+		var serr error
+		serr, err = lr.next()
+		switch {
+		case err == io.EOF:
+			return l, err
+		case err != nil:
+			d.report(ErrRead, SeverityFatal, "read failed", 0, "", "", err)
+			return l, d.abort
+		}
+		d.LineNum = lr.lineNum
+		d.offset = lr.offset
+		if serr != nil {
+			d.report(ErrSyntax, SeverityError, "malformed line", s.level, "", "", serr)
+			if d.abort != nil {
+				return l, d.abort
 			}
-			d.parsers[len(d.parsers)-1](s.level, string(s.tag), string(s.value), xref)
+			continue
+		}
+		break
+	}
 
+	l.level = s.level
+	if l.level > d.prevLevel+1 {
+		d.report(ErrLevelJump, SeverityWarning, "level jump", l.level, string(s.tag), string(s.value), nil)
+		if d.opts.Mode == Recover {
+			l.level = d.prevLevel + 1
 		}
+	}
+	d.prevLevel = l.level
 
-		// shift unparsed bytes to start of buffer
-		rest := copy(buf, buf[pos:])
+	if len(s.ref) > 0 {
+		l.xref = string(s.ref)
+	}
+	l.tag, l.value = lr.tag(), lr.value()
+	return l, d.abort
+}
 
-		// top up buffer
-		var num int
-		num, err = d.r.Read(buf[rest:len(buf)])
-		if err != nil {
-			if err == io.EOF {
-				err = nil
-			} else {
-				log.Println(err.Error())
-			}
-			break
+// parseLine passes a line to the current parser, and records its xrefs
+// if track is true
+func (d *Decoder) parseLine(l line, track bool) error {
+	if l.level < maxKnownLevel {
+		for len(d.known) <= l.level {
+			d.known = append(d.known, 0)
 		}
+		d.known = d.known[:l.level+1]
+	}
 
-		n = rest + num - 1
+	d.captured = false
+	err := d.parsers[len(d.parsers)-1](l.level, l.tag, l.value, l.xref)
+	if err == nil {
+		err = d.abort
+	}
+	if !d.captured && l.level < len(d.known) {
+		d.known[l.level]++
+	}
+	if err == nil && track {
+		d.track(l.level, l.tag, l.value, l.xref)
+	}
+	return err
+}
+
+// scan reads the lines of the input and passes each to handle
+func (d *Decoder) scan(ctx context.Context, handle func(l line) error) error {
+	lr := newLineReader(d.r)
+	done := ctx.Done()
+	open := false // a level 0 record is being decoded
+	for {
+		select {
+		case <-done:
+			return ctx.Err()
+		default:
+		}
 
+		l, err := d.nextLine(lr)
+		if err == io.EOF {
+			if open {
+				d.records++
+			}
+			d.progress()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if l.level == 0 && open {
+			d.records++
+			d.progress()
+		}
+		if err = handle(l); err != nil {
+			return err
+		}
+		open = true
 	}
-	return
 }
 
 type parser func(level int, tag string, value string, xref string) error
@@ -137,7 +354,8 @@ func (d *Decoder) pushParser(p parser) {
 func (d *Decoder) popParser(level int, tag string, value string, xref string) error {
 	n := len(d.parsers) - 1
 	if n < 1 {
-		log.Panic("MASSIVE ERROR") // TODO
+		d.report(ErrInternal, SeverityFatal, "parser stack underflow", level, tag, value, nil)
+		return d.abort
 	}
 	d.parsers = d.parsers[0:n]
 
@@ -148,19 +366,22 @@ func (d *Decoder) popParser(level int, tag string, value string, xref string) er
 func (d *Decoder) FindFamily(xref string) *FamilyRecord {
 	ref, found := d.refs[xref]
 	if !found || ref == nil {
-		// log.Printf("FindFamily(%s) not found.\n", xref)
 		return nil
 	}
 	f, found := ref.(*FamilyRecord)
 	if !found {
-		log.Fatalf("FindFamily(%s) not a Family\n", xref)
+		return nil
 	}
 	return f
 }
 
 // FindSource returns the SourceRecord for an xref
 func (d *Decoder) FindSource(xref string) *SourceRecord {
-	return d.refs[xref].(*SourceRecord)
+	s, found := d.refs[xref].(*SourceRecord)
+	if !found {
+		return nil
+	}
+	return s
 }
 
 // Level 0 record constructors
@@ -505,7 +726,7 @@ func makeAddressParser(d *Decoder, r *AddressRecord, minLevel int) parser {
 			d.pushParser(makeNoteParser(d, rec, level))
 
 		default:
-			log.Printf("unhandled Address tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Address", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -535,7 +756,7 @@ func makeAlbumParser(d *Decoder, r *AlbumRecord, minLevel int) parser {
 			d.pushParser(makePhotoParser(d, rec, level))
 
 		default:
-			log.Printf("unhandled Album tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Album", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -692,7 +913,7 @@ func makeAttributeParser(d *Decoder, r *AttributeRecord, minLevel int) parser {
 			//			r.UpdateTime_ = value
 
 		default:
-			log.Printf("unhandled Attribute tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Attribute", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -721,7 +942,7 @@ func makeAuthorParser(d *Decoder, r *AuthorRecord, minLevel int) parser {
 			r.Abbreviation = value
 
 		default:
-			log.Printf("unhandled Author tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Author", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -738,10 +959,10 @@ func makeBibliographyParser(d *Decoder, r *BibliographyRecord, minLevel int) par
 
 		case "COMP":
 			r.Component = r.Component + value
-			d.pushParser(makeTextParser(d, &r.Component, level))
+			d.pushParser(makeTextParser(d, &r.Component, &r.UnknownTags, level))
 
 		default:
-			log.Printf("unhandled bibliography record tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("bibliography record", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -767,7 +988,7 @@ func makeBlobParser(d *Decoder, r *BlobRecord, minLevel int) parser {
 			r.Data = r.Data + value
 
 		default:
-			log.Printf("unhandled Blob tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Blob", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -796,7 +1017,7 @@ func makeBusinessParser(d *Decoder, r *BusinessRecord, minLevel int) parser {
 			r.WebSite = value
 
 		default:
-			log.Printf("unhandled Business tagat %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Business", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -815,7 +1036,7 @@ func makeCallNumberParser(d *Decoder, r *CallNumberRecord, minLevel int) parser
 			r.Media = value
 
 		default:
-			log.Printf("unhandled CallNumber tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("CallNumber", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -841,7 +1062,7 @@ func makeChangeParser(d *Decoder, r *ChangeRecord, minLevel int) parser {
 			d.pushParser(makeNoteParser(d, rec, level))
 
 		default:
-			log.Printf("unhandled Change tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Change", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -860,7 +1081,7 @@ func makeCharacterSetParser(d *Decoder, r *CharacterSetRecord, minLevel int) par
 			r.Version = value
 
 		default:
-			log.Printf("unhandled CharacterSet tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("CharacterSet", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -879,7 +1100,7 @@ func makeChildStatusParser(d *Decoder, r *ChildStatusRecord, minLevel int) parse
 			r.Name = value
 
 		default:
-			log.Printf("unhandled ChildStatus tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("ChildStatus", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -961,7 +1182,7 @@ func makeCitationParser(d *Decoder, r *CitationRecord, minLevel int) parser {
 
 		case "TEXT":
 			r.Text = r.Text + value
-			d.pushParser(makeTextParser(d, &r.Text, level))
+			d.pushParser(makeTextParser(d, &r.Text, &r.UnknownTags, level))
 
 		case "DATE": // Leg8
 			r.Date = value
@@ -973,10 +1194,10 @@ func makeCitationParser(d *Decoder, r *CitationRecord, minLevel int) parser {
 			r.AppliesTo_ = value
 
 		case "_SUBQ", "_BIBL", "_TMPLT", "TID", "FIELD", "NAME", "VALUE": // RM6
-			// TODO
+			d.capture(&r.UnknownTags, level, tag, value, xref)
 
 		default:
-			log.Printf("unhandled Citation tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Citation", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -999,7 +1220,7 @@ func makeDataParser(d *Decoder, r *DataRecord, minLevel int) parser {
 
 		case "TEXT":
 			r.Text = r.Text + value
-			d.pushParser(makeTextParser(d, &r.Text, level))
+			d.pushParser(makeTextParser(d, &r.Text, &r.UnknownTags, level))
 
 		case "EVEN":
 			rec := &EventRecord{Level: level, Tag: tag, Value: value}
@@ -1015,7 +1236,7 @@ func makeDataParser(d *Decoder, r *DataRecord, minLevel int) parser {
 			d.pushParser(makeNoteParser(d, rec, level))
 
 		default:
-			log.Printf("unhandled Data tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Data", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -1035,7 +1256,7 @@ func makeDateParser(d *Decoder, r *DateRecord, minLevel int) parser {
 
 		case "TEXT":
 			r.Text = r.Text + value
-			d.pushParser(makeTextParser(d, &r.Text, level))
+			d.pushParser(makeTextParser(d, &r.Text, &r.UnknownTags, level))
 
 		case "DATD":
 			r.Day = value
@@ -1056,7 +1277,7 @@ func makeDateParser(d *Decoder, r *DateRecord, minLevel int) parser {
 			r.TimeZone_ = value
 
 		default:
-			log.Printf("unhandled Date tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Date", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -1104,10 +1325,10 @@ func makeEventDefinitionParser(d *Decoder, r *EventDefinitionRecord, minLevel in
 			"_SEN5", "_SEN6", "_SEN7", "_SEN8",
 			"_INC_NOTES", "_DEF", "_PP_EXCLUDE",
 			"_DATE_TYPE", "_PLACE_TYPE", "_CONF_FLAG":
-			// TODO
+			d.capture(&r.UnknownTags, level, tag, value, xref)
 
 		default:
-			log.Printf("unhandled Event Definition tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Event Definition", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -1261,7 +1482,7 @@ func makeEventParser(d *Decoder, r *EventRecord, minLevel int) parser {
 			r.UpdateTime_ = value
 
 		default:
-			log.Printf("unhandled Event tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Event", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -1275,14 +1496,6 @@ func makeFamilyLinkParser(d *Decoder, r *FamilyLink, minLevel int) parser {
 			return d.popParser(level, tag, value, xref)
 		}
 
-		//		r.SetFamily(d.FindFamily(value))
-		//		if r.family == nil {
-		//			log.Printf("Warning: Family not found for '%s'\n", value)
-		//			log.Printf("Line number: %d\nLevel: %d\nTag: %s\nValue: %s\nXref: %s\n",
-		//				d.LineNum, level, tag, value, xref)
-		//			d.CountWarnings()
-		//		}
-
 		switch tag {
 
 		case "PEDI":
@@ -1307,7 +1520,7 @@ func makeFamilyLinkParser(d *Decoder, r *FamilyLink, minLevel int) parser {
 			d.pushParser(makeCitationParser(d, rec, level))
 
 		default:
-			log.Printf("unhandled Family Link tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Family Link", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -1346,7 +1559,7 @@ func makeFamilyParser(d *Decoder, r *FamilyRecord, minLevel int) parser {
 		case "NCHI":
 			pint, err := strconv.ParseInt(value, 10, 64)
 			if err != nil {
-				log.Printf("NCHI = '%s': %s", value, err.Error())
+				d.report(ErrInvalidValue, SeverityWarning, "bad NCHI", level, tag, value, err)
 			}
 			r.NumChildren = int(pint)
 
@@ -1416,7 +1629,7 @@ func makeFamilyParser(d *Decoder, r *FamilyRecord, minLevel int) parser {
 			r.UpdateTime_ = value
 
 		default:
-			log.Printf("unhandled Family tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Family", &r.UnknownTags, level, tag, value, xref)
 		}
 		return nil
 	}
@@ -1432,10 +1645,10 @@ func makeFootnoteParser(d *Decoder, r *FootnoteRecord, minLevel int) parser {
 
 		case "COMP":
 			r.Component = r.Component + value
-			d.pushParser(makeTextParser(d, &r.Component, level))
+			d.pushParser(makeTextParser(d, &r.Component, &r.UnknownTags, level))
 
 		default:
-			log.Printf("unhandled Footnote tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Footnote", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -1457,7 +1670,7 @@ func makeGedcomParser(d *Decoder, r *GedcomRecord, minLevel int) parser {
 			r.Form = value
 
 		default:
-			log.Printf("unhandled Gedcom tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Gedcom", &r.UnknownTags, level, tag, value, xref)
 		}
 		return nil
 	}
@@ -1493,6 +1706,9 @@ func makeHeaderParser(d *Decoder, r *HeaderRecord, minLevel int) parser {
 			d.pushParser(makeGedcomParser(d, rec, level))
 
 		case "CHAR":
+			if _, decode := charsetByName(value); decode == nil {
+				d.report(ErrInvalidValue, SeverityWarning, "unknown character set, read as UTF-8", level, tag, value, nil)
+			}
 			rec := &CharacterSetRecord{Level: level, CharacterSet: value}
 			r.CharacterSet = rec
 			d.pushParser(makeCharacterSetParser(d, rec, level))
@@ -1562,7 +1778,7 @@ func makeHeaderParser(d *Decoder, r *HeaderRecord, minLevel int) parser {
 			r.HomePerson_ = rec
 
 		default:
-			log.Printf("unhandled Header tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Header", &r.UnknownTags, level, tag, value, xref)
 		}
 		return nil
 	}
@@ -1592,7 +1808,7 @@ func makeHistoryParser(d *Decoder, r *HistoryRecord, minLevel int) parser {
 			d.pushParser(makeCitationParser(d, rec, level))
 
 		default:
-			log.Printf("unhandled History tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("History", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -1632,7 +1848,7 @@ func makeIndividualLinkParser(d *Decoder, r *IndividualLink, minLevel int) parse
 			r.Preferred_ = value
 
 		default:
-			log.Printf("unhandled Individual Link tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Individual Link", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -1843,7 +2059,7 @@ func makeIndividualParser(d *Decoder, r *IndividualRecord, minLevel int) parser
 			r.Todo_ = append(r.Todo_, value)
 
 		default:
-			log.Printf("unhandled Individual tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Individual", &r.UnknownTags, level, tag, value, xref)
 		}
 		return nil
 	}
@@ -1858,7 +2074,7 @@ func makeMediaLinkParser(d *Decoder, r *MediaLink, minLevel int) parser {
 		switch tag {
 
 		default:
-			log.Printf("unhandled MediaLink tag at %d: %d %s %s\r", d.LineNum, level, tag, value)
+			d.unknown("MediaLink", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -1893,7 +2109,7 @@ func makeMediaParser(d *Decoder, r *MediaRecord, minLevel int) parser {
 
 		case "TEXT":
 			r.Text = value
-			d.pushParser(makeTextParser(d, &r.Text, level))
+			d.pushParser(makeTextParser(d, &r.Text, &r.UnknownTags, level))
 
 		case "NOTE":
 			rec := &NoteRecord{Level: level, Note: value}
@@ -1990,7 +2206,10 @@ func makeMediaParser(d *Decoder, r *MediaRecord, minLevel int) parser {
 				r.mediaLinks = append(r.mediaLinks, rec)
 				d.pushParser(makeMediaLinkParser(d, rec, level))
 			} else {
-				log.Fatal("OBJE inside OBJE")
+				rec := &MediaRecord{Level: level, Tag: tag}
+				link := &MediaLink{Level: level, Tag: tag, Value: value, Media: rec}
+				r.mediaLinks = append(r.mediaLinks, link)
+				d.pushParser(makeMediaParser(d, rec, level))
 			}
 
 		case "_SRCPP": // AQ15
@@ -2000,7 +2219,7 @@ func makeMediaParser(d *Decoder, r *MediaRecord, minLevel int) parser {
 			r.SrcFlip_ = value
 
 		default:
-			log.Printf("unhandled Media tag at %d: %d %s %s\r", d.LineNum, level, tag, value)
+			d.unknown("Media", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -2071,7 +2290,7 @@ func makeNameParser(d *Decoder, r *NameRecord, minLevel int) parser {
 			d.pushParser(makeNoteParser(d, rec, level))
 
 		default:
-			log.Printf("unhandled Name tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Name", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -2118,7 +2337,7 @@ func makeNoteParser(d *Decoder, r *NoteRecord, minLevel int) parser {
 			r.Description_ = value
 
 		default:
-			log.Printf("unhandled Note tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Note", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -2140,7 +2359,7 @@ func makePedigreeParser(d *Decoder, r *PedigreeRecord, minLevel int) parser {
 			r.Wife_ = value
 
 		default:
-			log.Printf("unhandled Pedigree tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Pedigree", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -2159,7 +2378,7 @@ func makePhoneParser(d *Decoder, r *PhoneRecord, minLevel int) parser {
 			r.Type_ = value
 
 		default:
-			log.Printf("unhandled Phone tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Phone", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -2181,7 +2400,7 @@ func makePhotoParser(d *Decoder, r *PhotoRecord, minLevel int) parser {
 			r.Prin_ = value
 
 		default:
-			log.Printf("unhandled Photo tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Photo", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -2203,7 +2422,7 @@ func makePlaceDefinitionParser(d *Decoder, r *PlaceDefinitionRecord, minLevel in
 			r.Abbreviation = value
 
 		default:
-			log.Printf("unhandled Place Definition tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Place Definition", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -2222,7 +2441,7 @@ func makePlacePartParser(d *Decoder, r *PlacePartRecord, minLevel int) parser {
 			r.Jurisdiction = value
 
 		default:
-			log.Printf("unhandled Place Part tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Place Part", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -2267,7 +2486,7 @@ func makePlaceParser(d *Decoder, r *PlaceRecord, minLevel int) parser {
 			d.pushParser(makeChangeParser(d, rec, level))
 
 		default:
-			log.Printf("unhandled Place tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Place", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -2298,8 +2517,20 @@ func makePublishParser(d *Decoder, r *PublishRecord, minLevel int) parser {
 			r.Disabled_ = value
 
 		default:
-			log.Printf("unhandled Publish_ tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Publish_", &r.UnknownTags, level, tag, value, xref)
+		}
+
+		return nil
+	}
+}
+
+// makeRawParser returns a parser for the subordinate lines of a RawLine
+func makeRawParser(d *Decoder, r *RawLines, minLevel int) parser {
+	return func(level int, tag string, value string, xref string) error {
+		if level <= minLevel {
+			return d.popParser(level, tag, value, xref)
 		}
+		*r = append(*r, &RawLine{Level: level, Xref: xref, Tag: tag, Value: value})
 
 		return nil
 	}
@@ -2324,7 +2555,7 @@ func makeRepositoryLinkParser(d *Decoder, r *RepositoryLink, minLevel int) parse
 			d.pushParser(makeNoteParser(d, rec, level))
 
 		default:
-			log.Printf("unhandled Repository Link tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Repository Link", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -2374,7 +2605,7 @@ func makeRepositoryParser(d *Decoder, r *RepositoryRecord, minLevel int) parser
 			d.pushParser(makeChangeParser(d, rec, level))
 
 		default:
-			log.Printf("unhandled Repository tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Repository", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -2393,7 +2624,7 @@ func makeRoleParser(d *Decoder, r *RoleRecord, minLevel int) parser {
 			r.Principal = value
 
 		default:
-			log.Printf("unhandled Role tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Role", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -2403,10 +2634,10 @@ func makeRoleParser(d *Decoder, r *RoleRecord, minLevel int) parser {
 // makeRootParser returns a parser for an RootRecord
 func makeRootParser(d *Decoder, r *RootRecord) parser {
 	return func(level int, tag string, value string, xref string) error {
-		//log.Println(level, tag, value, xref)
 		if level == 0 {
 			// cases ordered approx. by frequency of appearance
 			r.Level = level // always zero
+			d.xref = xref
 			switch tag {
 
 			case "INDI":
@@ -2467,6 +2698,7 @@ func makeRootParser(d *Decoder, r *RootRecord) parser {
 
 			case "NOTE":
 				rec := d.note(xref)
+				rec.Note = value
 				r.Note = append(r.Note, rec)
 				d.pushParser(makeNoteParser(d, rec, level))
 
@@ -2512,10 +2744,11 @@ func makeRootParser(d *Decoder, r *RootRecord) parser {
 				//d.pushParser(makeTrailerParser(d, obj, level))
 
 			default:
-				log.Printf("unhandled Root tag at %d: %d @%s@ %s %s\n", d.LineNum, level, xref, tag, value)
+				d.unknown("Root", &r.UnknownTags, level, tag, value, xref)
 			}
 		} else {
-			log.Printf("Not level 0 Root tag at %d: %d @%s@ %s %s\n", d.LineNum, level, xref, tag, value)
+			d.report(ErrSyntax, SeverityError, "Not level 0 Root tag", level, tag, value, nil)
+			d.capture(&r.UnknownTags, level, tag, value, xref)
 		}
 		return nil
 	}
@@ -2536,7 +2769,7 @@ func makeSchemaParser(d *Decoder, r *SchemaRecord, minLevel int) parser {
 			r.Data = append(r.Data, s)
 
 		default:
-			log.Printf("unhandled Schema tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Schema", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -2555,7 +2788,7 @@ func makeShortTitleParser(d *Decoder, r *ShortTitleRecord, minLevel int) parser
 			r.Indexed = value
 
 		default:
-			log.Printf("unhandled Short Title tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Short Title", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -2574,7 +2807,7 @@ func makeSlideShowParser(d *Decoder, r *SlideShowRecord, minLevel int) parser {
 			r.ShowTime_ = value
 
 		default:
-			log.Printf("unhandled Slide Show tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Slide Show", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -2604,11 +2837,11 @@ func makeSourceParser(d *Decoder, r *SourceRecord, minLevel int) parser {
 
 		case "NAME":
 			r.Name = value
-			d.pushParser(makeTextParser(d, &r.Name, level))
+			d.pushParser(makeTextParser(d, &r.Name, &r.UnknownTags, level))
 
 		case "TITL":
 			r.Title = value
-			d.pushParser(makeTextParser(d, &r.Title, level))
+			d.pushParser(makeTextParser(d, &r.Title, &r.UnknownTags, level))
 
 		case "AUTH":
 			rec := &AuthorRecord{Level: level, Author: value}
@@ -2617,11 +2850,11 @@ func makeSourceParser(d *Decoder, r *SourceRecord, minLevel int) parser {
 
 		case "ABBR":
 			r.Abbreviation = value
-			d.pushParser(makeTextParser(d, &r.Abbreviation, level))
+			d.pushParser(makeTextParser(d, &r.Abbreviation, &r.UnknownTags, level))
 
 		case "PUBL":
 			r.Publication = value
-			d.pushParser(makeTextParser(d, &r.Publication, level))
+			d.pushParser(makeTextParser(d, &r.Publication, &r.UnknownTags, level))
 
 		case "MEDI":
 			r.MediaType = value
@@ -2646,7 +2879,7 @@ func makeSourceParser(d *Decoder, r *SourceRecord, minLevel int) parser {
 
 		case "TEXT":
 			r.Text = r.Text + value
-			d.pushParser(makeTextParser(d, &r.Text, level))
+			d.pushParser(makeTextParser(d, &r.Text, &r.UnknownTags, level))
 
 		case "DATA":
 			rec := &DataRecord{Level: level, Data: value}
@@ -2717,10 +2950,10 @@ func makeSourceParser(d *Decoder, r *SourceRecord, minLevel int) parser {
 			d.pushParser(makeWebTagParser(d, rec, level))
 
 		case "_SUBQ", "_BIBL", "_TMPLT", "TID", "FIELD", "VALUE": // RM6
-			// TODO
+			d.capture(&r.UnknownTags, level, tag, value, xref)
 
 		default:
-			log.Printf("unhandled Source tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Source", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -2736,7 +2969,7 @@ func makeSubmissionLinkParser(d *Decoder, r *SubmissionLink, minLevel int) parse
 		switch tag {
 
 		default:
-			log.Printf("unhandled Submission Link tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Submission Link", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -2775,7 +3008,7 @@ func makeSubmissionParser(d *Decoder, r *SubmissionRecord, minLevel int) parser
 			r.RecordInternal = value
 
 		default:
-			log.Printf("unhandled Submission tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Submission", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -2791,7 +3024,7 @@ func makeSubmitterLinkParser(d *Decoder, r *SubmitterLink, minLevel int) parser
 		switch tag {
 
 		default:
-			log.Printf("unhandled Submitter Link tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Submitter Link", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -2869,7 +3102,7 @@ func makeSubmitterParser(d *Decoder, r *SubmitterRecord, minLevel int) parser {
 			d.pushParser(makeChangeParser(d, rec, level))
 
 		default:
-			log.Printf("unhandled Submitter tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Submitter", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -2904,14 +3137,15 @@ func makeSystemParser(d *Decoder, r *SystemRecord, minLevel int) parser {
 			r.RtlSave_ = value
 
 		default:
-			log.Printf("unhandled System tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("System", &r.UnknownTags, level, tag, value, xref)
 		}
 		return nil
 	}
 }
 
-// makeTextParser returns a parser for an string
-func makeTextParser(d *Decoder, s *string, minLevel int) parser {
+// makeTextParser returns a parser for an string, keeping unknown lines
+// under it in u
+func makeTextParser(d *Decoder, s *string, u *RawLines, minLevel int) parser {
 	return func(level int, tag string, value string, xref string) error {
 		if level <= minLevel {
 			return d.popParser(level, tag, value, xref)
@@ -2930,7 +3164,11 @@ func makeTextParser(d *Decoder, s *string, minLevel int) parser {
 			*s = *s + value
 
 		default:
-			log.Printf("unhandled Text tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			// kept in the record, written after the text
+			d.unknown("Text", u, level, tag, value, xref)
+			if minLevel < len(d.known) {
+				(*u)[len(*u)-1].Position = d.known[minLevel] + 1
+			}
 		}
 
 		return nil
@@ -2949,7 +3187,7 @@ func makeTitleParser(d *Decoder, r *TitleRecord, minLevel int) parser {
 			r.Abbreviation = value
 
 		default:
-			log.Printf("unhandled Title tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Title", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -2986,7 +3224,7 @@ func makeTodoParser(d *Decoder, r *TodoRecord, minLevel int) parser {
 			r.Date2_ = value
 
 		default:
-			log.Printf("unhandled Todo tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("Todo", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -3005,7 +3243,7 @@ func makeUserReferenceNumberParser(d *Decoder, r *UserReferenceNumberRecord, min
 			r.Type = value
 
 		default:
-			log.Printf("unhandled UserReferenceNumber tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("UserReferenceNumber", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
@@ -3037,38 +3275,36 @@ func makeWebTagParser(d *Decoder, r *WebTagRecord, minLevel int) parser {
 			r.URL = value
 
 		default:
-			log.Printf("unhandled WebTag tag at %d: %d %s %s\n", d.LineNum, level, tag, value)
+			d.unknown("WebTag", &r.UnknownTags, level, tag, value, xref)
 		}
 
 		return nil
 	}
 }
 
-// stripXref removes value and surrounding @s from xref
+// isPointer reports whether a value is a pointer like @I1@ rather than text
+func isPointer(value string) bool {
+	n := len(value)
+	if n < 3 || value[0] != '@' || value[n-1] != '@' || value[1] == '#' {
+		return false
+	}
+	return strings.IndexAny(value[1:n-1], "@ \t") < 0
+}
+
+// stripXref returns the pointer which is or ends a value, like @I1@ in
+// CHIL @I1@, or "" if there is none
 func stripXref(value string) string {
-	return value
-	//	if value == "" {
-	//		return ""
-	//	}
-	//	if value[0] == '@' {
-	//		return strings.Trim(value, "@")
-	//	}
-	//	atIndex := strings.IndexByte(value, '@')
-	//	if atIndex >= 0 {
-	//		return strings.Trim(value[atIndex:], "@")
-	//	}
-	//	return ""
-}
-
-// stripValue removes @ bracketed xref from value
+	xref := value[strings.LastIndexByte(value, ' ')+1:]
+	if isPointer(xref) {
+		return xref
+	}
+	return ""
+}
+
+// stripValue returns a value without the pointer which ends it
 func stripValue(value string) string {
+	if xref := stripXref(value); xref != "" {
+		return strings.TrimRight(value[:len(value)-len(xref)], " ")
+	}
 	return value
-	//	if value == "" {
-	//		return ""
-	//	}
-	//	atIndex := strings.IndexByte(value, '@')
-	//	if atIndex >= 0 {
-	//		return strings.Trim(value[:atIndex], " ")
-	//	}
-	//	return value
 }
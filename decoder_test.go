@@ -7,6 +7,7 @@ package gedcom
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -14,6 +15,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"unicode/utf16"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/go-test/deep"
@@ -341,6 +343,8 @@ func TestIndiAttribute2(t *testing.T) {
 		},
 	}
 
+	att1.Citation[0].SetSource(d.FindSource("@SOURCE1@")) // linked by Resolve
+
 	if len(i1.Attribute) > 0 {
 
 		if !reflect.DeepEqual(i1.Attribute[0], att1) {
@@ -391,6 +395,7 @@ func TestIndiParents2(t *testing.T) {
 			Pedigree: "birth",
 		},
 	}
+	fam1.SetFamily(d.FindFamily("@PARENTS@")) // linked by Resolve
 
 	if !reflect.DeepEqual(i1.Parents[0], fam1) {
 		t.Errorf("Family 0 parents 0 was: \n%q\nExpected: \n%q\n",
@@ -540,3 +545,307 @@ func TestSource2(t *testing.T) {
 	}
 
 }
+
+func TestDecodeErrors(t *testing.T) {
+	input := "0 HEAD\n1 CHAR UTF-8\n0 @I1@ INDI\n1 NAME John /Doe/\nX BAD\n1 ZZZZ Odd\n0 TRLR\n"
+	d := NewDecoder(strings.NewReader(input))
+
+	g, err := d.Decode()
+	if err != nil {
+		t.Fatalf("Decode returned error %v, expected no error", err)
+	}
+	if len(g.Individual) != 1 {
+		t.Fatalf("Decode found %d individuals, expected 1", len(g.Individual))
+	}
+
+	if len(d.Errors) != 2 {
+		t.Fatalf("Decode reported %d errors, expected 2: %v", len(d.Errors), d.Errors)
+	}
+
+	e := d.Errors[0]
+	if e.Kind != ErrSyntax || e.Severity != SeverityError || e.Line != 5 || e.Xref != "@I1@" {
+		t.Errorf("Decode reported %#v, expected syntax error at line 5 in @I1@", e)
+	}
+	if e.Offset != int64(strings.Index(input, "X BAD")) {
+		t.Errorf("Decode reported offset %d, expected %d", e.Offset, strings.Index(input, "X BAD"))
+	}
+
+	e = d.Errors[1]
+	if e.Kind != ErrUnhandledTag || e.Severity != SeverityWarning || e.Line != 6 || e.Tag != "ZZZZ" || e.Value != "Odd" {
+		t.Errorf("Decode reported %#v, expected unhandled ZZZZ tag at line 6", e)
+	}
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	inputs := []string{
+		"0 HEAD\n0 @ INDI\n0 TRLR\n",
+		"0 HEAD\n0 @@ INDI\n0 TRLR\n",
+		"0 HEAD\n0 @I1 INDI\n0 TRLR\n",
+		"0 HEAD\n0 I1@ INDI\n0 TRLR\n",
+		"0 HEAD\n1 @ NOTE\n0 TRLR\n",
+	}
+
+	for _, input := range inputs {
+		// the same input as UTF-16LE with a byte order mark
+		utf16le := []byte{0xFF, 0xFE}
+		for _, u := range utf16.Encode([]rune(input)) {
+			utf16le = append(utf16le, byte(u), byte(u>>8))
+		}
+
+		for _, data := range [][]byte{[]byte(input), utf16le} {
+			for _, opts := range []DecoderOptions{{Mode: Lenient}, {Mode: Strict}, {Mode: Recover}, {Workers: 4}} {
+				d := NewDecoderWithOptions(bytes.NewReader(data), opts)
+				_, err := d.Decode()
+				if opts.Mode == Strict {
+					if e, ok := err.(*DecodeError); !ok || e.Kind != ErrSyntax || e.Line != 2 {
+						t.Errorf("Strict Decode of %q returned %v, expected a syntax error at line 2", input, err)
+					}
+					continue
+				}
+				if err != nil || len(d.Errors) != 1 || d.Errors[0].Kind != ErrSyntax || d.Errors[0].Line != 2 {
+					t.Errorf("Decode of %q with %+v returned %v, %v, expected a syntax error at line 2", input, opts, err, d.Errors)
+				}
+			}
+
+			d := NewDecoder(bytes.NewReader(data))
+			for {
+				if _, err := d.Next(); err != nil {
+					break
+				}
+			}
+			if len(d.Errors) != 1 || d.Errors[0].Kind != ErrSyntax {
+				t.Errorf("Next of %q reported %v, expected a syntax error", input, d.Errors)
+			}
+
+			if _, err := ReadTree(bytes.NewReader(data)); err == nil {
+				t.Errorf("ReadTree of %q returned no error, expected a syntax error", input)
+			}
+		}
+	}
+}
+
+func TestDecoderModes(t *testing.T) {
+	input := "0 HEAD\n0 @I1@ INDI\n1 NAME John /Doe/\n3 GIVN John\n1 ZZZZ Odd\n0 TRLR\n"
+
+	d := NewDecoderWithOptions(strings.NewReader(input), DecoderOptions{Mode: Lenient})
+	if _, err := d.Decode(); err != nil {
+		t.Fatalf("Lenient Decode returned error %v, expected no error", err)
+	}
+	if len(d.Errors) != 2 || d.Errors[0].Kind != ErrLevelJump || d.Errors[1].Kind != ErrUnhandledTag {
+		t.Errorf("Lenient Decode reported %v, expected level jump and unhandled tag", d.Errors)
+	}
+	if d.CountWarnings() != 2 {
+		t.Errorf("Lenient Decode counted %d warnings, expected 2", d.CountWarnings())
+	}
+
+	d = NewDecoderWithOptions(strings.NewReader(input), DecoderOptions{Mode: Strict})
+	_, err := d.Decode()
+	e, ok := err.(*DecodeError)
+	if !ok || e.Kind != ErrLevelJump || e.Severity != SeverityFatal || e.Line != 4 {
+		t.Errorf("Strict Decode returned %v, expected fatal level jump at line 4", err)
+	}
+
+	d = NewDecoderWithOptions(strings.NewReader(input), DecoderOptions{Mode: Recover})
+	g, err := d.Decode()
+	if err != nil {
+		t.Fatalf("Recover Decode returned error %v, expected no error", err)
+	}
+	if g.Individual[0].Name[0].GivenName != "John" {
+		t.Errorf("Recover Decode lost GIVN under a level jump")
+	}
+
+	stop := fmt.Errorf("stop")
+	var seen []Diagnostic
+	d = NewDecoderWithOptions(strings.NewReader(input), DecoderOptions{
+		OnDiagnostic: func(diag Diagnostic) error {
+			seen = append(seen, diag)
+			if diag.Kind == ErrUnhandledTag {
+				return stop
+			}
+			return nil
+		},
+	})
+	if _, err := d.Decode(); err != stop {
+		t.Errorf("Decode returned %v, expected the handler's error", err)
+	}
+	if len(seen) != 2 || seen[1].Line != 5 {
+		t.Errorf("handler saw %v, expected 2 diagnostics ending at line 5", seen)
+	}
+}
+
+func TestUnknownTags(t *testing.T) {
+	input := "0 HEAD\n" +
+		"0 @I1@ INDI\n" +
+		"1 NAME John /Doe/\n" +
+		"2 _VEND Extra\n" +
+		"3 DATE 1 JAN 1900\n" +
+		"1 SEX M\n" +
+		"1 _CUSTOM @X1@\n" +
+		"0 @X1@ _VENDOR_RECORD\n" +
+		"1 NOTE kept\n" +
+		"0 TRLR\n"
+	d := NewDecoder(strings.NewReader(input))
+
+	g, err := d.Decode()
+	if err != nil {
+		t.Fatalf("Decode returned error %v, expected no error", err)
+	}
+
+	if len(d.Errors) != 3 {
+		t.Errorf("Decode reported %d errors, expected 3: %v", len(d.Errors), d.Errors)
+	}
+
+	indi := g.Individual[0]
+	name := RawLines{
+		{Level: 2, Tag: "_VEND", Value: "Extra", Position: 1},
+		{Level: 3, Tag: "DATE", Value: "1 JAN 1900"},
+	}
+	if diff := deep.Equal(indi.Name[0].UnknownTags, name); diff != nil {
+		t.Error(diff)
+	}
+	if indi.Sex != "M" {
+		t.Errorf("Individual sex was %q, expected M", indi.Sex)
+	}
+
+	var buf bytes.Buffer
+	if _, err := g.Write(&buf); err != nil {
+		t.Fatalf("Write returned error %v, expected no error", err)
+	}
+	for _, line := range []string{
+		"    2 _VEND Extra\n      3 DATE 1 JAN 1900\n",
+		"  1 _CUSTOM @X1@\n",
+		"0 @X1@ _VENDOR_RECORD\n  1 NOTE kept\n0 TRLR\n",
+	} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("Write output did not contain %q:\n%s", line, buf.String())
+		}
+	}
+}
+
+func TestUnknownTagsOrder(t *testing.T) {
+	lines := []string{
+		"0 HEAD",
+		"0 _FIRST before the records",
+		"0 @I1@ INDI",
+		"1 _A first",
+		"1 NAME John /Doe/",
+		"2 _B before GIVN",
+		"2 GIVN John",
+		"2 _C last in NAME",
+		"1 _D between",
+		"2 _E under _D",
+		"1 SEX M",
+		"1 _F last",
+		"0 _ROOT between records",
+		"0 @I2@ INDI",
+		"1 NAME Jane /Doe/",
+		"0 TRLR",
+	}
+	input := strings.Join(lines, "\n") + "\n"
+
+	defer func(n int) { chunkLines = n }(chunkLines)
+	chunkLines = 2
+
+	for _, workers := range []int{0, 4} {
+		g, err := NewDecoderWithOptions(strings.NewReader(input), DecoderOptions{Workers: workers}).Decode()
+		if err != nil {
+			t.Fatalf("Decode returned error %v, expected no error", err)
+		}
+
+		var buf bytes.Buffer
+		if _, err := g.Write(&buf); err != nil {
+			t.Fatalf("Write returned error %v, expected no error", err)
+		}
+		for name, output := range map[string]string{"Write": buf.String(), "String": g.String()} {
+			var got []string
+			for _, line := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+				got = append(got, strings.TrimLeft(line, " "))
+			}
+			if diff := deep.Equal(got, lines); diff != nil {
+				t.Errorf("%s with %d workers changed the order of lines: %v", name, workers, diff)
+			}
+		}
+	}
+}
+
+func TestUnknownTagsUnderText(t *testing.T) {
+	lines := []string{
+		"0 HEAD",
+		"0 @S1@ SOUR",
+		"1 _X before the title",
+		"2 _Y under _X",
+		"1 TITL The title",
+		"2 CONT second line",
+		"2 _VEND under TITL",
+		"3 DATE 1 JAN 1900",
+		"1 ABBR Short",
+		"1 TEXT The text",
+		"2 _Z under TEXT",
+		"0 TRLR",
+	}
+	input := strings.Join(lines, "\n") + "\n"
+
+	for _, workers := range []int{0, 4} {
+		g, err := NewDecoderWithOptions(strings.NewReader(input), DecoderOptions{Workers: workers}).Decode()
+		if err != nil {
+			t.Fatalf("Decode returned error %v, expected no error", err)
+		}
+		if g.Source[0].Title != "The title\nsecond line" {
+			t.Errorf("Decode with %d workers read TITL %q", workers, g.Source[0].Title)
+		}
+
+		var buf bytes.Buffer
+		if _, err := g.Write(&buf); err != nil {
+			t.Fatalf("Write returned error %v, expected no error", err)
+		}
+		for name, output := range map[string]string{"Write": buf.String(), "String": g.String()} {
+			var got []string
+			for _, line := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+				got = append(got, strings.TrimLeft(line, " "))
+			}
+			if diff := deep.Equal(got, lines); diff != nil {
+				t.Errorf("%s with %d workers did not keep the lines under text: %v", name, workers, diff)
+			}
+		}
+	}
+}
+
+func TestDecodeContext(t *testing.T) {
+	input := "0 HEAD\n0 @I1@ INDI\n1 NAME John /Doe/\n0 @I2@ INDI\n0 @I3@ INDI\n0 @I4@ INDI\n0 TRLR\n"
+
+	var seen []Progress
+	d := NewDecoderWithOptions(strings.NewReader(input), DecoderOptions{
+		OnProgress: func(p Progress) { seen = append(seen, p) },
+	})
+	if _, err := d.DecodeContext(context.Background()); err != nil {
+		t.Fatalf("DecodeContext returned error %v", err)
+	}
+	want := []Progress{
+		{Bytes: int64(len(input)), Lines: 2, Records: 1},
+		{Bytes: int64(len(input)), Lines: 4, Records: 2},
+		{Bytes: int64(len(input)), Lines: 5, Records: 3},
+		{Bytes: int64(len(input)), Lines: 6, Records: 4},
+		{Bytes: int64(len(input)), Lines: 7, Records: 5},
+		{Bytes: int64(len(input)), Lines: 7, Records: 6},
+	}
+	if diff := deep.Equal(seen, want); diff != nil {
+		t.Errorf("progress: %v", diff)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d = NewDecoderWithOptions(strings.NewReader(input), DecoderOptions{
+		OnProgress: func(p Progress) {
+			if p.Records == 2 {
+				cancel()
+			}
+		},
+	})
+	g, err := d.DecodeContext(ctx)
+	if err != context.Canceled {
+		t.Fatalf("DecodeContext returned %v, expected %v", err, context.Canceled)
+	}
+	if len(g.Individual) != 2 {
+		t.Errorf("DecodeContext decoded %d individuals before it was cancelled, expected 2", len(g.Individual))
+	}
+}
@@ -0,0 +1,271 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// EncoderOptions controls the output of an Encoder
+type EncoderOptions struct {
+	// Charset is one of CharsetUTF8, CharsetUTF16LE, CharsetUTF16BE,
+	// CharsetANSEL or CharsetASCII. The default is CharsetUTF8.
+	Charset string
+
+	// BOM writes a byte order mark before UTF-8 output.
+	// UTF-16 output always starts with a byte order mark.
+	BOM bool
+}
+
+// An Encoder writes GEDCOM objects to an output stream in a chosen
+// character set.
+type Encoder struct {
+	w      io.Writer
+	opts   EncoderOptions
+	Errors []*EncodeError // characters which could not be written
+}
+
+// NewEncoder returns a new encoder that writes UTF-8 to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// NewEncoderWithOptions returns a new encoder that writes to w using opts.
+func NewEncoderWithOptions(w io.Writer, opts EncoderOptions) *Encoder {
+	return &Encoder{w: w, opts: opts}
+}
+
+// headChar returns the HEAD.CHAR value for the character set of e
+func (e *Encoder) headChar() (string, error) {
+	switch e.opts.Charset {
+	case "", CharsetUTF8:
+		return "UTF-8", nil
+	case CharsetUTF16LE, CharsetUTF16BE:
+		return "UNICODE", nil
+	case CharsetANSEL:
+		return "ANSEL", nil
+	case CharsetASCII:
+		return "ASCII", nil
+	}
+	return "", fmt.Errorf("gedcom: cannot encode character set %q", e.opts.Charset)
+}
+
+// Encode writes r to the output stream. HEAD.CHAR is written to match the
+// character set of the output, but r is not changed. In CharsetASCII other
+// characters are written as escapes like @#U+00E9@, which the Decoder reads
+// back. In CharsetANSEL characters which cannot be written, or a diacritic
+// with no character before it to modify, are replaced by '?' and recorded
+// in Errors, so the output loses them.
+func (e *Encoder) Encode(r *RootRecord) error {
+	char, err := e.headChar()
+	if err != nil {
+		return err
+	}
+
+	if r.Header != nil {
+		saved := r.Header.CharacterSet
+		defer func() { r.Header.CharacterSet = saved }()
+
+		rec := &CharacterSetRecord{Level: r.Header.Level + 1, CharacterSet: char}
+		if saved != nil {
+			rec.Level = saved.Level
+			rec.Version = saved.Version
+			rec.UnknownTags = saved.UnknownTags
+		}
+		r.Header.CharacterSet = rec
+	}
+
+	cw := &charsetWriter{w: e.w, e: e}
+	switch {
+	case e.opts.Charset == CharsetUTF16LE:
+		_, err = e.w.Write([]byte{0xFF, 0xFE})
+	case e.opts.Charset == CharsetUTF16BE:
+		_, err = e.w.Write([]byte{0xFE, 0xFF})
+	case e.opts.BOM && (e.opts.Charset == "" || e.opts.Charset == CharsetUTF8):
+		_, err = e.w.Write([]byte{0xEF, 0xBB, 0xBF})
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Write(cw)
+	if err == nil && len(cw.pending) > 0 {
+		err = cw.flush(cw.pending)
+	}
+	return err
+}
+
+// charsetWriter converts UTF-8 written to it to the character set of an Encoder
+type charsetWriter struct {
+	w       io.Writer
+	e       *Encoder
+	line    int    // lines written so far
+	pending []byte // incomplete UTF-8 from the end of the previous Write
+	buf     []byte
+}
+
+// Write converts p and writes it to the output stream
+func (c *charsetWriter) Write(p []byte) (n int, err error) {
+	data := append(c.pending, p...)
+
+	// hold back an incomplete character at the end, or in ANSEL an
+	// incomplete line, as diacritics may follow
+	i := len(data)
+	if c.e.opts.Charset == CharsetANSEL {
+		i = bytes.LastIndexAny(data, "\n\r") + 1
+	}
+	for j := len(data) - 1; j >= i && j >= len(data)-utf8.UTFMax; j-- {
+		if utf8.RuneStart(data[j]) {
+			if !utf8.FullRune(data[j:]) {
+				i = j
+			}
+			break
+		}
+	}
+	c.pending = append([]byte(nil), data[i:]...)
+
+	if err = c.flush(data[:i]); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// flush converts complete UTF-8 in p and writes it to the output stream
+func (c *charsetWriter) flush(p []byte) error {
+	c.buf = c.buf[:0]
+	charset := c.e.opts.Charset
+	markPos := -1 // where an ANSEL diacritic goes, before its character
+
+	for len(p) > 0 {
+		r, size := utf8.DecodeRune(p)
+		p = p[size:]
+		if r == '\n' {
+			c.line++
+		}
+
+		switch charset {
+		case "", CharsetUTF8:
+			c.buf = appendRune(c.buf, r)
+
+		case CharsetUTF16LE, CharsetUTF16BE:
+			c.buf = appendUTF16(c.buf, r, charset == CharsetUTF16BE)
+
+		case CharsetASCII:
+			if r < utf8.RuneSelf {
+				c.buf = append(c.buf, byte(r))
+			} else {
+				c.buf = append(c.buf, fmt.Sprintf("@#U+%04X@", r)...)
+			}
+
+		case CharsetANSEL:
+			if b, found := anselMarks[r]; found {
+				if markPos < 0 {
+					c.fail(r)
+					continue
+				}
+				// a diacritic following its character moves in front of it
+				c.buf = append(c.buf, 0)
+				copy(c.buf[markPos+1:], c.buf[markPos:])
+				c.buf[markPos] = b
+				markPos++
+				continue
+			}
+			markPos = len(c.buf)
+			if r == '\n' || r == '\r' || r == ' ' {
+				markPos = -1 // nothing for a diacritic to modify
+			}
+			var ok bool
+			if c.buf, ok = appendANSEL(c.buf, r); !ok {
+				c.fail(r)
+			}
+		}
+	}
+
+	_, err := c.w.Write(c.buf)
+	return err
+}
+
+// fail writes '?' for r, which cannot be written, and records it
+func (c *charsetWriter) fail(r rune) {
+	c.buf = append(c.buf, '?')
+	c.e.Errors = append(c.e.Errors, &EncodeError{Line: c.line + 1, Charset: c.e.opts.Charset, Rune: r})
+}
+
+// appendUTF16 appends the UTF-16 encoding of r to p
+func appendUTF16(p []byte, r rune, bigEndian bool) []byte {
+	var units []uint16
+	if r >= 0x10000 {
+		r -= 0x10000
+		units = []uint16{uint16(0xD800 + r>>10), uint16(0xDC00 + r&0x3FF)}
+	} else {
+		units = []uint16{uint16(r)}
+	}
+	for _, u := range units {
+		if bigEndian {
+			p = append(p, byte(u>>8), byte(u))
+		} else {
+			p = append(p, byte(u), byte(u>>8))
+		}
+	}
+	return p
+}
+
+// appendANSEL appends the ANSEL encoding of r to p, with any diacritics
+// before the character they modify. It reports false if r cannot be
+// encoded, and p is unchanged.
+func appendANSEL(p []byte, r rune) ([]byte, bool) {
+	var marks []byte
+	for {
+		if r < utf8.RuneSelf {
+			p = append(p, marks...)
+			return append(p, byte(r)), true
+		}
+		if b, found := anselBytes[r]; found {
+			p = append(p, marks...)
+			return append(p, b), true
+		}
+		pair, found := anselDecompose[r]
+		if !found {
+			return p, false
+		}
+		marks = append([]byte{anselMarks[pair[1]]}, marks...)
+		r = pair[0]
+	}
+}
+
+// anselBytes maps runes to the ANSEL bytes for spacing characters
+var anselBytes = func() map[rune]byte {
+	m := make(map[rune]byte)
+	for i, r := range anselRunes {
+		if b := byte(i + 0x80); r >= utf8.RuneSelf && !isAnselCombining(b) {
+			m[r] = b
+		}
+	}
+	return m
+}()
+
+// anselMarks maps combining diacritics to ANSEL bytes
+var anselMarks = func() map[rune]byte {
+	m := make(map[rune]byte)
+	for i, r := range anselRunes {
+		if b := byte(i + 0x80); isAnselCombining(b) {
+			m[r] = b
+		}
+	}
+	return m
+}()
+
+// anselDecompose maps precomposed runes to a base rune and a diacritic
+var anselDecompose = func() map[rune][2]rune {
+	m := make(map[rune][2]rune)
+	for pair, r := range anselCompose {
+		m[r] = pair
+	}
+	return m
+}()
@@ -0,0 +1,111 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEncoderRoundTrip(t *testing.T) {
+	text := charsetText(t, "testdata/kennedy.ged", "UTF-8", true, "")
+	g, err := NewDecoder(strings.NewReader(text)).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := g.String()
+
+	for _, charset := range []string{CharsetUTF8, CharsetUTF16LE, CharsetUTF16BE, CharsetANSEL, CharsetASCII} {
+		var buf bytes.Buffer
+		e := NewEncoderWithOptions(&buf, EncoderOptions{Charset: charset})
+		if err := e.Encode(g); err != nil {
+			t.Fatalf("%s: Encode returned error %v, expected no error", charset, err)
+		}
+		if len(e.Errors) != 0 {
+			t.Errorf("%s: Encode reported %v, expected no errors", charset, e.Errors)
+		}
+		if g.Header.CharacterSet.CharacterSet != "UTF-8" {
+			t.Errorf("%s: Encode changed HEAD.CHAR of its input to %s", charset, g.Header.CharacterSet.CharacterSet)
+		}
+
+		d := NewDecoder(&buf)
+		g2, err := d.Decode()
+		if err != nil {
+			t.Fatalf("%s: Decode returned error %v, expected no error", charset, err)
+		}
+		if d.charset != charset {
+			t.Errorf("%s: Decode detected %s", charset, d.charset)
+		}
+		g2.Header.CharacterSet = g.Header.CharacterSet
+		if got := g2.String(); got != want {
+			t.Errorf("%s: Decode of the output did not match the input", charset)
+		}
+	}
+}
+
+func TestEncoderASCII(t *testing.T) {
+	g := &RootRecord{
+		Header:     &HeaderRecord{CharacterSet: &CharacterSetRecord{Level: 1, CharacterSet: "ANSEL"}},
+		Individual: IndividualRecords{{Xref: "@I1@", Name: NameRecords{{Level: 1, Name: "Zoë /Brontë/"}}}},
+	}
+
+	var buf bytes.Buffer
+	e := NewEncoderWithOptions(&buf, EncoderOptions{Charset: CharsetASCII})
+	if err := e.Encode(g); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "1 CHAR ASCII\n") {
+		t.Errorf("Encode did not rewrite HEAD.CHAR:\n%s", out)
+	}
+	if !strings.Contains(out, "1 NAME Zo@#U+00EB@ /Bront@#U+00EB@/\n") {
+		t.Errorf("Encode did not escape non-ASCII characters:\n%s", out)
+	}
+	if len(e.Errors) != 0 {
+		t.Errorf("Encode reported %v, expected no errors", e.Errors)
+	}
+
+	g2, err := NewDecoder(strings.NewReader(out)).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := g2.Individual[0].Name[0].Name; name != "Zoë /Brontë/" {
+		t.Errorf("Decode of the escapes returned %q, expected %q", name, "Zoë /Brontë/")
+	}
+}
+
+func TestEncoderANSELErrors(t *testing.T) {
+	g := &RootRecord{
+		Header: &HeaderRecord{},
+		Individual: IndividualRecords{
+			{Xref: "@I1@", Name: NameRecords{{Level: 1, Name: "Mao /毛/"}}},
+			{Xref: "@I2@", Name: NameRecords{{Level: 1, Name: "René /Dupont/"}}},
+			{Xref: "@I3@", Name: NameRecords{{Level: 1, Name: "\u0301Ana /Lee/"}}},
+		},
+	}
+
+	var buf bytes.Buffer
+	e := NewEncoderWithOptions(&buf, EncoderOptions{Charset: CharsetANSEL})
+	if err := e.Encode(g); err != nil {
+		t.Fatal(err)
+	}
+	if len(e.Errors) != 2 || e.Errors[0].Rune != '毛' || e.Errors[0].Line != 4 || e.Errors[1].Rune != '\u0301' || e.Errors[1].Line != 8 {
+		t.Errorf("Encode reported %v, expected 毛 at line 4 and U+0301 at line 8", e.Errors)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("1 NAME ?Ana /Lee/\n")) {
+		t.Errorf("Encode did not replace the diacritic with no character:\n%q", buf.String())
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("1 NAME Mao /?/\n")) {
+		t.Errorf("Encode did not replace 毛:\n%q", buf.String())
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("1 NAME Ren\xe2e /Dupont/\n")) {
+		t.Errorf("Encode did not move the diacritic before its character:\n%q", buf.String())
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("0 HEAD\n  1 CHAR ANSEL\n")) {
+		t.Errorf("Encode did not add HEAD.CHAR:\n%q", buf.String())
+	}
+}
@@ -0,0 +1,154 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrorKind classifies a problem found while decoding
+type ErrorKind int
+
+const (
+	ErrSyntax       ErrorKind = iota // line could not be scanned
+	ErrUnhandledTag                  // tag not understood in its context
+	ErrInvalidValue                  // value could not be interpreted
+	ErrRead                          // the input could not be read
+	ErrInternal                      // the decoder reached an impossible state
+	ErrLevelJump                     // level more than one deeper than the line before
+)
+
+var errorKindNames = [...]string{
+	ErrSyntax:       "syntax error",
+	ErrUnhandledTag: "unhandled tag",
+	ErrInvalidValue: "invalid value",
+	ErrRead:         "read error",
+	ErrInternal:     "internal error",
+	ErrLevelJump:    "level jump",
+}
+
+// String returns the name of the error kind
+func (k ErrorKind) String() string {
+	if k >= 0 && int(k) < len(errorKindNames) {
+		return errorKindNames[k]
+	}
+	return fmt.Sprintf("ErrorKind(%d)", int(k))
+}
+
+// Severity grades a problem found while decoding
+type Severity int
+
+const (
+	SeverityWarning Severity = iota // data was skipped or kept verbatim
+	SeverityError                   // data was lost or is malformed
+	SeverityFatal                   // decoding could not continue
+)
+
+var severityNames = [...]string{
+	SeverityWarning: "warning",
+	SeverityError:   "error",
+	SeverityFatal:   "fatal",
+}
+
+// String returns the name of the severity
+func (s Severity) String() string {
+	if s >= 0 && int(s) < len(severityNames) {
+		return severityNames[s]
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// DecodeError describes a problem found at a line of the input
+type DecodeError struct {
+	Line     int       // line number, starting at 1
+	Offset   int64     // byte offset of the start of the line in the input
+	Level    int       // level of the line
+	Tag      string    // tag of the line
+	Value    string    // value of the line
+	Xref     string    // xref_id of the enclosing level 0 record
+	Kind     ErrorKind // what went wrong
+	Severity Severity  // how badly it went wrong
+	Msg      string    // description of the problem
+	Err      error     // underlying error, if any
+}
+
+// Diagnostic is a problem passed to DecoderOptions.OnDiagnostic
+type Diagnostic = DecodeError
+
+// Error formats the problem with its position in the input
+func (e *DecodeError) Error() string {
+	var ss []string
+
+	ss = append(ss, fmt.Sprintf("%s: %s at %d", e.Severity, e.Msg, e.Line))
+	if e.Tag != "" {
+		s := fmt.Sprintf("%d %s", e.Level, e.Tag)
+		if e.Value != "" {
+			s += " " + e.Value
+		}
+		ss = append(ss, s)
+	}
+	if e.Xref != "" {
+		ss = append(ss, "in "+e.Xref)
+	}
+	if e.Err != nil {
+		ss = append(ss, e.Err.Error())
+	}
+
+	return strings.Join(ss, ": ")
+}
+
+// Unwrap returns the underlying error, if any
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// EncodeError describes a character which cannot be written in the
+// character set of an Encoder
+type EncodeError struct {
+	Line    int    // line number of the output, starting at 1
+	Charset string // character set of the output
+	Rune    rune   // character which cannot be written
+}
+
+// Error formats the problem with its position in the output
+func (e *EncodeError) Error() string {
+	return fmt.Sprintf("cannot write %U %q in %s at %d", e.Rune, e.Rune, e.Charset, e.Line)
+}
+
+// DateError describes a date value which cannot be parsed
+type DateError struct {
+	Value string // the date value
+	Msg   string // description of the problem
+}
+
+// Error formats the problem with the date value
+func (e *DateError) Error() string {
+	return fmt.Sprintf("invalid date %q: %s", e.Value, e.Msg)
+}
+
+// AgeError describes an age value which cannot be parsed
+type AgeError struct {
+	Value string // the age value
+	Msg   string // description of the problem
+}
+
+// Error formats the problem with the age value
+func (e *AgeError) Error() string {
+	return fmt.Sprintf("invalid age %q: %s", e.Value, e.Msg)
+}
+
+// QueryError describes a query which cannot be parsed
+type QueryError struct {
+	Query string // the query
+	Pos   int    // byte offset of the problem in the query
+	Msg   string // description of the problem
+}
+
+// Error formats the problem with its position in the query
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("invalid query %q: %s at %d", e.Query, e.Msg, e.Pos)
+}
@@ -0,0 +1,93 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"strings"
+)
+
+// ValuePart is a run of text or an escape sequence in a value
+type ValuePart struct {
+	Text   string // the text, or the escape between @# and @, like DJULIAN
+	Escape bool   // the part is an escape sequence
+}
+
+// escapeEnd returns the index of the @ which ends an escape sequence
+// starting at value[i], or -1 if there is none there
+func escapeEnd(value string, i int) int {
+	if i+2 >= len(value) || value[i] != '@' || value[i+1] != '#' {
+		return -1
+	}
+	if j := strings.IndexByte(value[i+2:], '@'); j > 0 {
+		return i + 2 + j
+	}
+	return -1
+}
+
+// SplitEscapes splits a decoded value into text and the escape sequences
+// in it, like @#DJULIAN@ in a date
+func SplitEscapes(value string) []ValuePart {
+	var parts []ValuePart
+	start := 0
+	for i := 0; i < len(value); i++ {
+		if j := escapeEnd(value, i); j >= 0 {
+			if i > start {
+				parts = append(parts, ValuePart{Text: value[start:i]})
+			}
+			parts = append(parts, ValuePart{Text: value[i+2 : j], Escape: true})
+			start, i = j+1, j
+		}
+	}
+	if start < len(value) {
+		parts = append(parts, ValuePart{Text: value[start:]})
+	}
+	return parts
+}
+
+// unescapeValue returns a value as read, with each @@ in text replaced by
+// @. Pointers, escape sequences and single @s are left as they are.
+func unescapeValue(value string) string {
+	if strings.IndexByte(value, '@') < 0 || isPointer(value) {
+		return value
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if j := escapeEnd(value, i); j >= 0 {
+			b.WriteString(value[i : j+1])
+			i = j
+			continue
+		}
+		b.WriteByte(value[i])
+		if value[i] == '@' && i+1 < len(value) && value[i+1] == '@' {
+			i++
+		}
+	}
+	return b.String()
+}
+
+// escapeValue returns a value to be written, with each @ in text doubled.
+// Pointers and escape sequences are left as they are, so text which looks
+// like one is written as one.
+func escapeValue(value string) string {
+	if strings.IndexByte(value, '@') < 0 || isPointer(value) {
+		return value
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if j := escapeEnd(value, i); j >= 0 {
+			b.WriteString(value[i : j+1])
+			i = j
+			continue
+		}
+		if value[i] == '@' {
+			b.WriteByte('@')
+		}
+		b.WriteByte(value[i])
+	}
+	return b.String()
+}
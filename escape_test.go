@@ -0,0 +1,139 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+var escapeExamples = []struct {
+	raw   string // as read and written
+	value string // as decoded
+}{
+	{"", ""},
+	{"plain text", "plain text"},
+	{"@I1@", "@I1@"},
+	{"john@@example.com", "john@example.com"},
+	{"@@ at the start, at the end @@", "@ at the start, at the end @"},
+	{"@#DJULIAN@ 1 JAN 1700", "@#DJULIAN@ 1 JAN 1700"},
+	{"@#DFRENCH R@ 1 VEND 10", "@#DFRENCH R@ 1 VEND 10"},
+	{"caf@#U+00E9@ @@home", "caf@#U+00E9@ @home"},
+}
+
+func TestEscapeValue(t *testing.T) {
+	for _, ex := range escapeExamples {
+		if v := unescapeValue(ex.raw); v != ex.value {
+			t.Errorf("unescapeValue(%q) returned %q, expected %q", ex.raw, v, ex.value)
+		}
+		if raw := escapeValue(ex.value); raw != ex.raw {
+			t.Errorf("escapeValue(%q) returned %q, expected %q", ex.value, raw, ex.raw)
+		}
+	}
+
+	// single @s are read as they are and written doubled
+	if v := unescapeValue("john@example.com"); v != "john@example.com" {
+		t.Errorf(`unescapeValue("john@example.com") returned %q`, v)
+	}
+	if raw := escapeValue("john@example.com"); raw != "john@@example.com" {
+		t.Errorf(`escapeValue("john@example.com") returned %q`, raw)
+	}
+}
+
+func TestSplitEscapes(t *testing.T) {
+	examples := []struct {
+		value string
+		parts []ValuePart
+	}{
+		{"", nil},
+		{"1 JAN 1700", []ValuePart{{"1 JAN 1700", false}}},
+		{"@#DJULIAN@ 1 JAN 1700", []ValuePart{{"DJULIAN", true}, {" 1 JAN 1700", false}}},
+		{"caf@#U+00E9@", []ValuePart{{"caf", false}, {"U+00E9", true}}},
+		{"a @ b @#X@@#Y@", []ValuePart{{"a @ b ", false}, {"X", true}, {"Y", true}}},
+		{"@#unterminated", []ValuePart{{"@#unterminated", false}}},
+	}
+
+	for _, ex := range examples {
+		if diff := deep.Equal(SplitEscapes(ex.value), ex.parts); diff != nil {
+			t.Errorf("SplitEscapes(%q): %v", ex.value, diff)
+		}
+	}
+}
+
+func TestStripXref(t *testing.T) {
+	examples := []struct {
+		value, xref, text string
+	}{
+		{"@I1@", "@I1@", ""},
+		{"CHIL @I1@", "@I1@", "CHIL"},
+		{"Child", "", "Child"},
+		{"Son of Head", "", "Son of Head"},
+		{"", "", ""},
+	}
+
+	for _, ex := range examples {
+		if xref := stripXref(ex.value); xref != ex.xref {
+			t.Errorf("stripXref(%q) returned %q, expected %q", ex.value, xref, ex.xref)
+		}
+		if text := stripValue(ex.value); text != ex.text {
+			t.Errorf("stripValue(%q) returned %q, expected %q", ex.value, text, ex.text)
+		}
+	}
+}
+
+func TestDecodeEscapes(t *testing.T) {
+	input := `0 HEAD
+1 CHAR UTF-8
+0 @I1@ INDI
+1 NAME John /Smith/
+1 BIRT
+2 DATE @#DJULIAN@ 1 JAN 1700
+2 ROLE CHIL @I2@
+2 ROLE Child
+1 NOTE mail john@@example.com
+2 CONT or jane@example.com
+0 @I2@ INDI
+0 TRLR
+`
+	g, err := NewDecoder(bytes.NewReader([]byte(input))).Decode()
+	if err != nil {
+		t.Fatalf("Decode returned error %v", err)
+	}
+
+	indi := g.Individual[0]
+	if note := indi.Note[0].Note; note != "mail john@example.com\nor jane@example.com" {
+		t.Errorf("NOTE was %q", note)
+	}
+	birt := indi.Event[0]
+	if date := birt.Date.Date; date != "@#DJULIAN@ 1 JAN 1700" {
+		t.Errorf("DATE was %q", date)
+	}
+	if r := birt.Role[0]; r.Role != "CHIL" || r.Individual != g.Individual[1] {
+		t.Errorf("ROLE 0 was %q, %v", r.Role, r.Individual)
+	}
+	if r := birt.Role[1]; r.Role != "Child" || r.Individual.Xref != "" {
+		t.Errorf("ROLE 1 was %q, %q", r.Role, r.Individual.Xref)
+	}
+
+	var buf bytes.Buffer
+	if _, err := indi.Write(&buf); err != nil {
+		t.Fatalf("Write returned error %v", err)
+	}
+	for _, line := range []string{
+		"2 DATE @#DJULIAN@ 1 JAN 1700\n",
+		"2 ROLE CHIL @I2@\n",
+		"2 ROLE Child\n",
+		"1 NOTE mail john@@example.com\n",
+		"2 CONT or jane@@example.com\n",
+	} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("Write did not write %q in\n%s", line, buf.String())
+		}
+	}
+}
@@ -0,0 +1,232 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"strings"
+)
+
+// Pedigree values of FAMC.PEDI
+const (
+	PedigreeBirth   = "birth"
+	PedigreeAdopted = "adopted"
+	PedigreeFoster  = "foster"
+	PedigreeSealing = "sealing"
+)
+
+// PedigreeType returns the PEDI value of a FAMC link in lower case, or
+// birth when it has none
+func (r *FamilyLink) PedigreeType() string {
+	if r.Pedigree == nil || r.Pedigree.Pedigree == "" {
+		return PedigreeBirth
+	}
+	return strings.ToLower(r.Pedigree.Pedigree)
+}
+
+// parentPedigree returns the pedigree of a FAMC link to the husband or
+// the wife of the family, which may differ from PEDI (MH/FTB8)
+func (r *FamilyLink) parentPedigree(husband bool) string {
+	if r.Pedigree != nil {
+		if husband && r.Pedigree.Husband_ != "" {
+			return strings.ToLower(r.Pedigree.Husband_)
+		}
+		if !husband && r.Pedigree.Wife_ != "" {
+			return strings.ToLower(r.Pedigree.Wife_)
+		}
+	}
+	return r.PedigreeType()
+}
+
+// matchPedigree reports whether pedigree is one of pedigrees, or whether
+// pedigrees is empty
+func matchPedigree(pedigree string, pedigrees []string) bool {
+	if len(pedigrees) == 0 {
+		return true
+	}
+	for _, p := range pedigrees {
+		if strings.EqualFold(pedigree, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// individualSet collects individuals once each, in the order they are added
+type individualSet struct {
+	list IndividualRecords
+	seen map[*IndividualRecord]bool
+}
+
+// add adds an individual which is not nil or excluded
+func (s *individualSet) add(i *IndividualRecord, exclude ...*IndividualRecord) {
+	if i == nil || s.seen[i] {
+		return
+	}
+	for _, x := range exclude {
+		if i == x {
+			return
+		}
+	}
+	if s.seen == nil {
+		s.seen = make(map[*IndividualRecord]bool)
+	}
+	s.seen[i] = true
+	s.list = append(s.list, i)
+}
+
+// linked returns the individual of a link, or nil
+func linked(link *IndividualLink) *IndividualRecord {
+	if link == nil {
+		return nil
+	}
+	return link.Individual
+}
+
+// Partners returns the husband and the wife of the family which are present
+func (r *FamilyRecord) Partners() IndividualRecords {
+	var s individualSet
+	s.add(linked(r.Husband))
+	s.add(linked(r.Wife))
+	return s.list
+}
+
+// childPedigree returns the pedigree of a child of the family from the
+// child's FAMC link to it, or birth when there is no such link
+func (r *FamilyRecord) childPedigree(child *IndividualRecord, husband bool) string {
+	for _, link := range child.Parents {
+		if link.Family() == r {
+			return link.parentPedigree(husband)
+		}
+	}
+	return PedigreeBirth
+}
+
+// parents returns the husbands or the wives of the families the individual
+// is a child of, through FAMC links with one of pedigrees
+func (r *IndividualRecord) parents(husband bool, pedigrees []string) IndividualRecords {
+	var s individualSet
+	for _, link := range r.Parents {
+		fam := link.Family()
+		if fam == nil || !matchPedigree(link.parentPedigree(husband), pedigrees) {
+			continue
+		}
+		if husband {
+			s.add(linked(fam.Husband), r)
+		} else {
+			s.add(linked(fam.Wife), r)
+		}
+	}
+	return s.list
+}
+
+// Fathers returns the husbands of the families the individual is a child
+// of. With pedigrees, like PedigreeBirth, only FAMC links with one of those
+// PEDI values are followed.
+func (r *IndividualRecord) Fathers(pedigrees ...string) IndividualRecords {
+	return r.parents(true, pedigrees)
+}
+
+// Mothers returns the wives of the families the individual is a child
+// of. With pedigrees only FAMC links with one of those PEDI values are
+// followed.
+func (r *IndividualRecord) Mothers(pedigrees ...string) IndividualRecords {
+	return r.parents(false, pedigrees)
+}
+
+// Spouses returns the other partners of the families the individual is a
+// spouse in
+func (r *IndividualRecord) Spouses() IndividualRecords {
+	var s individualSet
+	for _, link := range r.Family {
+		if fam := link.Family(); fam != nil {
+			s.add(linked(fam.Husband), r)
+			s.add(linked(fam.Wife), r)
+		}
+	}
+	return s.list
+}
+
+// Children returns the children of the families the individual is a
+// spouse in. With pedigrees only children whose FAMC link to the family has
+// one of those PEDI values are included.
+func (r *IndividualRecord) Children(pedigrees ...string) IndividualRecords {
+	var s individualSet
+	for _, link := range r.Family {
+		fam := link.Family()
+		if fam == nil {
+			continue
+		}
+		husband := linked(fam.Husband) == r
+		for _, child := range fam.Child {
+			if c := child.Individual; c != nil && matchPedigree(fam.childPedigree(c, husband), pedigrees) {
+				s.add(c, r)
+			}
+		}
+	}
+	return s.list
+}
+
+// Siblings returns the other children of the families the individual is a
+// child of. With pedigrees only FAMC links with one of those PEDI values are
+// followed, from the individual and from the siblings.
+func (r *IndividualRecord) Siblings(pedigrees ...string) IndividualRecords {
+	var s individualSet
+	for _, link := range r.Parents {
+		fam := link.Family()
+		if fam == nil || !matchPedigree(link.PedigreeType(), pedigrees) {
+			continue
+		}
+		for _, child := range fam.Child {
+			if c := child.Individual; c != nil && matchPedigree(fam.childPedigree(c, true), pedigrees) {
+				s.add(c, r)
+			}
+		}
+	}
+	return s.list
+}
+
+// HalfSiblings returns the children of the parents of the individual who
+// share only one parent with the individual. Children of another family
+// of the same two parents are full siblings. Pedigrees are followed as by
+// Fathers, Mothers and Children.
+func (r *IndividualRecord) HalfSiblings(pedigrees ...string) IndividualRecords {
+	full := make(map[*IndividualRecord]bool)
+	for _, sibling := range r.Siblings(pedigrees...) {
+		full[sibling] = true
+	}
+
+	var s individualSet
+	parents := r.allParents(pedigrees)
+	for _, parent := range parents {
+		for _, child := range parent.Children(pedigrees...) {
+			if !full[child] && !sameIndividuals(child.allParents(pedigrees), parents) {
+				s.add(child, r)
+			}
+		}
+	}
+	return s.list
+}
+
+// allParents returns the fathers and then the mothers of the individual
+func (r *IndividualRecord) allParents(pedigrees []string) IndividualRecords {
+	return append(r.Fathers(pedigrees...), r.Mothers(pedigrees...)...)
+}
+
+// sameIndividuals reports whether a and b hold the same individuals, in
+// any order
+func sameIndividuals(a, b IndividualRecords) bool {
+	var sa, sb individualSet
+	for _, i := range a {
+		sa.add(i)
+	}
+	for _, i := range b {
+		if !sa.seen[i] {
+			return false
+		}
+		sb.add(i)
+	}
+	return len(sa.list) == len(sb.list)
+}
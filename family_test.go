@@ -0,0 +1,109 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+var familyInput = `0 HEAD
+0 @FATHER@ INDI
+1 FAMS @F1@
+1 FAMS @F2@
+1 FAMS @F3@
+0 @MOTHER@ INDI
+1 FAMS @F1@
+1 FAMS @F3@
+0 @CHILD@ INDI
+1 FAMC @F1@
+2 PEDI birth
+0 @ADOPTED@ INDI
+1 FAMC @F1@
+2 PEDI Adopted
+0 @STEPMOTHER@ INDI
+1 FAMS @F2@
+0 @HALF@ INDI
+1 FAMC @F2@
+0 @LATER@ INDI
+1 FAMC @F3@
+0 @F1@ FAM
+1 HUSB @FATHER@
+1 WIFE @MOTHER@
+1 CHIL @CHILD@
+1 CHIL @ADOPTED@
+0 @F2@ FAM
+1 HUSB @FATHER@
+1 WIFE @STEPMOTHER@
+1 CHIL @HALF@
+0 @F3@ FAM
+1 HUSB @FATHER@
+1 WIFE @MOTHER@
+1 CHIL @LATER@
+0 TRLR
+`
+
+// xrefs returns the xrefs of individuals, for comparing
+func xrefs(indis IndividualRecords) []string {
+	var ss []string
+	for _, i := range indis {
+		ss = append(ss, i.Xref)
+	}
+	return ss
+}
+
+func TestFamilyNavigation(t *testing.T) {
+	d := NewDecoder(bytes.NewReader([]byte(familyInput)))
+	g, err := d.Decode()
+	if err != nil {
+		t.Fatalf("Decode returned error %v", err)
+	}
+	indi := make(map[string]*IndividualRecord)
+	for _, i := range g.Individual {
+		indi[i.Xref] = i
+	}
+
+	examples := []struct {
+		name string
+		got  IndividualRecords
+		want []string
+	}{
+		{"Fathers", indi["@CHILD@"].Fathers(), []string{"@FATHER@"}},
+		{"Mothers", indi["@CHILD@"].Mothers(), []string{"@MOTHER@"}},
+		{"Mothers adopted", indi["@ADOPTED@"].Mothers(PedigreeAdopted), []string{"@MOTHER@"}},
+		{"Mothers birth of adopted", indi["@ADOPTED@"].Mothers(PedigreeBirth), nil},
+		{"Spouses", indi["@FATHER@"].Spouses(), []string{"@MOTHER@", "@STEPMOTHER@"}},
+		{"Children", indi["@FATHER@"].Children(), []string{"@CHILD@", "@ADOPTED@", "@HALF@", "@LATER@"}},
+		{"Children birth", indi["@MOTHER@"].Children(PedigreeBirth), []string{"@CHILD@", "@LATER@"}},
+		{"Siblings", indi["@CHILD@"].Siblings(), []string{"@ADOPTED@"}},
+		{"Siblings birth", indi["@CHILD@"].Siblings(PedigreeBirth), nil},
+		{"HalfSiblings", indi["@CHILD@"].HalfSiblings(), []string{"@HALF@"}},
+		{"HalfSiblings of half", indi["@HALF@"].HalfSiblings(), []string{"@CHILD@", "@ADOPTED@", "@LATER@"}},
+		{"HalfSiblings in a second family", indi["@LATER@"].HalfSiblings(), []string{"@HALF@"}},
+		{"Partners", g.Family[1].Partners(), []string{"@FATHER@", "@STEPMOTHER@"}},
+	}
+
+	for _, ex := range examples {
+		if diff := deep.Equal(xrefs(ex.got), ex.want); diff != nil {
+			t.Errorf("%s: %v", ex.name, diff)
+		}
+	}
+}
+
+func TestParentPedigree(t *testing.T) {
+	link := &FamilyLink{Pedigree: &PedigreeRecord{Pedigree: "birth", Wife_: "Adopted"}}
+	if p := link.parentPedigree(true); p != PedigreeBirth {
+		t.Errorf("pedigree of husband = %q, expected %q", p, PedigreeBirth)
+	}
+	if p := link.parentPedigree(false); p != PedigreeAdopted {
+		t.Errorf("pedigree of wife = %q, expected %q", p, PedigreeAdopted)
+	}
+	if p := (&FamilyLink{}).PedigreeType(); p != PedigreeBirth {
+		t.Errorf("PedigreeType without PEDI = %q, expected %q", p, PedigreeBirth)
+	}
+}
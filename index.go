@@ -0,0 +1,402 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// Record is a level 0 record, like *IndividualRecord or *FamilyRecord
+type Record interface {
+	Write(w io.Writer) (nbytes int, err error)
+	String() string
+}
+
+// Index provides lookups of the records of a RootRecord by xref and by
+// surname, _UID, RIN, REFN and _FSFTID. Records added or removed with Add
+// and Remove are kept in both the RootRecord and the Index; after changing
+// the identifiers of a record, call Update.
+type Index struct {
+	root     *RootRecord
+	xrefs    map[string][]Record // records with each xref, the first of which is returned
+	surnames map[string]IndividualRecords
+	uids     map[string][]Record
+	rins     map[string][]Record
+	refns    map[string][]Record
+	fsftids  map[string]IndividualRecords
+	keys     map[Record]*indexKeys // keys each record is indexed by
+}
+
+// indexKeys holds the secondary keys of a record
+type indexKeys struct {
+	xref     string
+	surnames []string
+	uids     []string
+	rins     []string
+	refns    []string
+	fsftid   string
+}
+
+// NewIndex returns an Index of the level 0 records of r
+func NewIndex(r *RootRecord) *Index {
+	x := &Index{
+		root:     r,
+		xrefs:    make(map[string][]Record),
+		surnames: make(map[string]IndividualRecords),
+		uids:     make(map[string][]Record),
+		rins:     make(map[string][]Record),
+		refns:    make(map[string][]Record),
+		fsftids:  make(map[string]IndividualRecords),
+		keys:     make(map[Record]*indexKeys),
+	}
+	for _, rec := range r.records() {
+		x.insert(rec)
+	}
+	return x
+}
+
+// records returns the level 0 records which an Index holds
+func (r *RootRecord) records() []Record {
+	var recs []Record
+	for _, x := range r.Individual {
+		recs = append(recs, x)
+	}
+	for _, x := range r.Family {
+		recs = append(recs, x)
+	}
+	for _, x := range r.Source {
+		recs = append(recs, x)
+	}
+	for _, x := range r.Note {
+		recs = append(recs, x)
+	}
+	for _, x := range r.Media {
+		recs = append(recs, x)
+	}
+	for _, x := range r.Repository {
+		recs = append(recs, x)
+	}
+	for _, x := range r.Submitter {
+		recs = append(recs, x)
+	}
+	for _, x := range r.Submission {
+		recs = append(recs, x)
+	}
+	for _, x := range r.Place {
+		recs = append(recs, x)
+	}
+	for _, x := range r.Event {
+		recs = append(recs, x)
+	}
+	return recs
+}
+
+// refns returns the REFN values of a record
+func refns(refs UserReferenceNumberRecords) []string {
+	var ss []string
+	for _, ref := range refs {
+		ss = append(ss, ref.UserReferenceNumber)
+	}
+	return ss
+}
+
+// keysOf returns the keys of a record, or nil if an Index cannot hold it
+func keysOf(rec Record) *indexKeys {
+	switch x := rec.(type) {
+	case *IndividualRecord:
+		k := &indexKeys{xref: x.Xref, uids: x.UniqueId_, rins: x.Rin, refns: refns(x.UserReferenceNumber), fsftid: x.FamilySearchFTID_}
+		for _, name := range x.Name {
+			if surname := name.Parse().Surname; surname != "" {
+				k.surnames = append(k.surnames, strings.ToUpper(surname))
+			}
+		}
+		return k
+	case *FamilyRecord:
+		return &indexKeys{xref: x.Xref, uids: x.UniqueId_, rins: x.Rin, refns: refns(x.UserReferenceNumber)}
+	case *SourceRecord:
+		return &indexKeys{xref: x.Xref, rins: x.Rin, refns: refns(x.UserReferenceNumber)}
+	case *NoteRecord:
+		return &indexKeys{xref: x.Xref, refns: refns(x.UserReferenceNumber)}
+	case *MediaRecord:
+		return &indexKeys{xref: x.Xref, refns: refns(x.UserReferenceNumber)}
+	case *RepositoryRecord:
+		return &indexKeys{xref: x.Xref, refns: refns(x.UserReferenceNumber)}
+	case *SubmitterRecord:
+		return &indexKeys{xref: x.Xref, rins: x.Rin}
+	case *SubmissionRecord:
+		return &indexKeys{xref: x.Xref}
+	case *PlaceRecord:
+		return &indexKeys{xref: x.Xref}
+	case *EventRecord:
+		return &indexKeys{xref: x.Xref, uids: x.UniqueId_, rins: x.Rin}
+	}
+	return nil
+}
+
+// insert adds a record to the maps of the Index
+func (x *Index) insert(rec Record) {
+	k := keysOf(rec)
+	if k == nil {
+		return
+	}
+	x.keys[rec] = k
+
+	if k.xref != "" {
+		x.xrefs[k.xref] = appendRecord(x.xrefs[k.xref], rec)
+	}
+	if indi, ok := rec.(*IndividualRecord); ok {
+		for _, s := range k.surnames {
+			x.surnames[s] = appendIndividual(x.surnames[s], indi)
+		}
+		if k.fsftid != "" {
+			x.fsftids[k.fsftid] = appendIndividual(x.fsftids[k.fsftid], indi)
+		}
+	}
+	for _, s := range k.uids {
+		x.uids[s] = appendRecord(x.uids[s], rec)
+	}
+	for _, s := range k.rins {
+		x.rins[s] = appendRecord(x.rins[s], rec)
+	}
+	for _, s := range k.refns {
+		x.refns[s] = appendRecord(x.refns[s], rec)
+	}
+}
+
+// delete removes a record from the maps of the Index
+func (x *Index) delete(rec Record) {
+	k, found := x.keys[rec]
+	if !found {
+		return
+	}
+	delete(x.keys, rec)
+
+	x.xrefs[k.xref] = removeRecord(x.xrefs[k.xref], rec)
+	if len(x.xrefs[k.xref]) == 0 {
+		delete(x.xrefs, k.xref)
+	}
+	if indi, ok := rec.(*IndividualRecord); ok {
+		for _, s := range k.surnames {
+			x.surnames[s] = removeIndividual(x.surnames[s], indi)
+			if len(x.surnames[s]) == 0 {
+				delete(x.surnames, s)
+			}
+		}
+		x.fsftids[k.fsftid] = removeIndividual(x.fsftids[k.fsftid], indi)
+		if len(x.fsftids[k.fsftid]) == 0 {
+			delete(x.fsftids, k.fsftid)
+		}
+	}
+	for _, m := range []struct {
+		index map[string][]Record
+		keys  []string
+	}{{x.uids, k.uids}, {x.rins, k.rins}, {x.refns, k.refns}} {
+		for _, s := range m.keys {
+			m.index[s] = removeRecord(m.index[s], rec)
+			if len(m.index[s]) == 0 {
+				delete(m.index, s)
+			}
+		}
+	}
+}
+
+// appendRecord appends a record unless it is already present
+func appendRecord(recs []Record, rec Record) []Record {
+	for _, r := range recs {
+		if r == rec {
+			return recs
+		}
+	}
+	return append(recs, rec)
+}
+
+// removeRecord removes a record
+func removeRecord(recs []Record, rec Record) []Record {
+	for i, r := range recs {
+		if r == rec {
+			return append(recs[:i:i], recs[i+1:]...)
+		}
+	}
+	return recs
+}
+
+// appendIndividual appends an individual unless it is already present
+func appendIndividual(indis IndividualRecords, indi *IndividualRecord) IndividualRecords {
+	for _, i := range indis {
+		if i == indi {
+			return indis
+		}
+	}
+	return append(indis, indi)
+}
+
+// removeIndividual removes an individual
+func removeIndividual(indis IndividualRecords, indi *IndividualRecord) IndividualRecords {
+	for i, x := range indis {
+		if x == indi {
+			return append(indis[:i:i], indis[i+1:]...)
+		}
+	}
+	return indis
+}
+
+// list returns a pointer to the slice of the RootRecord which holds
+// records like rec, or nil if an Index cannot hold it
+func (r *RootRecord) list(rec Record) interface{} {
+	switch rec.(type) {
+	case *IndividualRecord:
+		return &r.Individual
+	case *FamilyRecord:
+		return &r.Family
+	case *SourceRecord:
+		return &r.Source
+	case *NoteRecord:
+		return &r.Note
+	case *MediaRecord:
+		return &r.Media
+	case *RepositoryRecord:
+		return &r.Repository
+	case *SubmitterRecord:
+		return &r.Submitter
+	case *SubmissionRecord:
+		return &r.Submission
+	case *PlaceRecord:
+		return &r.Place
+	case *EventRecord:
+		return &r.Event
+	}
+	return nil
+}
+
+// Add appends a level 0 record to the RootRecord and indexes it
+func (x *Index) Add(rec Record) error {
+	list := x.root.list(rec)
+	if list == nil {
+		return fmt.Errorf("gedcom: cannot index %T", rec)
+	}
+	v := reflect.ValueOf(list).Elem()
+	v.Set(reflect.Append(v, reflect.ValueOf(rec)))
+	x.insert(rec)
+	return nil
+}
+
+// Remove removes a level 0 record from the RootRecord and the Index. Links
+// to the record are left as they are; see RootRecord.Resolve.
+func (x *Index) Remove(rec Record) error {
+	list := x.root.list(rec)
+	if list == nil {
+		return fmt.Errorf("gedcom: cannot index %T", rec)
+	}
+	v := reflect.ValueOf(list).Elem()
+	found := false
+	for i := 0; i < v.Len(); i++ {
+		if v.Index(i).Interface() == rec {
+			v.Set(reflect.AppendSlice(v.Slice3(0, i, i), v.Slice(i+1, v.Len())))
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("gedcom: %T %s is not in the RootRecord", rec, keysOf(rec).xref)
+	}
+	x.delete(rec)
+	return nil
+}
+
+// Update indexes a record again after its xref, names or other
+// identifiers have changed
+func (x *Index) Update(rec Record) {
+	if _, found := x.keys[rec]; found {
+		x.delete(rec)
+		x.insert(rec)
+	}
+}
+
+// Record returns the record with an xref, or nil
+func (x *Index) Record(xref string) Record {
+	if recs := x.xrefs[xref]; len(recs) > 0 {
+		return recs[0]
+	}
+	return nil
+}
+
+// Individual returns the individual with an xref, or nil
+func (x *Index) Individual(xref string) *IndividualRecord {
+	rec, _ := x.Record(xref).(*IndividualRecord)
+	return rec
+}
+
+// Family returns the family with an xref, or nil
+func (x *Index) Family(xref string) *FamilyRecord {
+	rec, _ := x.Record(xref).(*FamilyRecord)
+	return rec
+}
+
+// Source returns the source with an xref, or nil
+func (x *Index) Source(xref string) *SourceRecord {
+	rec, _ := x.Record(xref).(*SourceRecord)
+	return rec
+}
+
+// Note returns the note with an xref, or nil
+func (x *Index) Note(xref string) *NoteRecord {
+	rec, _ := x.Record(xref).(*NoteRecord)
+	return rec
+}
+
+// Media returns the media object with an xref, or nil
+func (x *Index) Media(xref string) *MediaRecord {
+	rec, _ := x.Record(xref).(*MediaRecord)
+	return rec
+}
+
+// Repository returns the repository with an xref, or nil
+func (x *Index) Repository(xref string) *RepositoryRecord {
+	rec, _ := x.Record(xref).(*RepositoryRecord)
+	return rec
+}
+
+// Submitter returns the submitter with an xref, or nil
+func (x *Index) Submitter(xref string) *SubmitterRecord {
+	rec, _ := x.Record(xref).(*SubmitterRecord)
+	return rec
+}
+
+// Submission returns the submission with an xref, or nil
+func (x *Index) Submission(xref string) *SubmissionRecord {
+	rec, _ := x.Record(xref).(*SubmissionRecord)
+	return rec
+}
+
+// The By methods return new slices, which the caller may change.
+
+// BySurname returns the individuals with a name with the surname, ignoring case
+func (x *Index) BySurname(surname string) IndividualRecords {
+	return append(IndividualRecords(nil), x.surnames[strings.ToUpper(surname)]...)
+}
+
+// ByUID returns the records with a _UID
+func (x *Index) ByUID(uid string) []Record {
+	return append([]Record(nil), x.uids[uid]...)
+}
+
+// ByRIN returns the records with a RIN
+func (x *Index) ByRIN(rin string) []Record {
+	return append([]Record(nil), x.rins[rin]...)
+}
+
+// ByREFN returns the records with a REFN
+func (x *Index) ByREFN(refn string) []Record {
+	return append([]Record(nil), x.refns[refn]...)
+}
+
+// ByFSFTID returns the individuals with a _FSFTID
+func (x *Index) ByFSFTID(id string) IndividualRecords {
+	return append(IndividualRecords(nil), x.fsftids[id]...)
+}
@@ -0,0 +1,158 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+var indexInput = `0 HEAD
+0 @I1@ INDI
+1 NAME John /Smith/
+1 _UID 1234-ABCD
+1 RIN 17
+1 REFN R-1
+1 _FSFTID LZ4X-ABC
+0 @I2@ INDI
+1 NAME Mary /smith/
+1 NAME Mary /Jones/
+0 @F1@ FAM
+1 HUSB @I1@
+1 RIN 17
+0 @S1@ SOUR
+1 REFN R-1
+0 @N1@ NOTE A note
+0 TRLR
+`
+
+func TestIndex(t *testing.T) {
+	d := NewDecoder(bytes.NewReader([]byte(indexInput)))
+	g, err := d.Decode()
+	if err != nil {
+		t.Fatalf("Decode returned error %v", err)
+	}
+	x := NewIndex(g)
+
+	if i := x.Individual("@I1@"); i != g.Individual[0] {
+		t.Errorf("Individual(@I1@) = %v", i)
+	}
+	if f := x.Family("@F1@"); f != g.Family[0] {
+		t.Errorf("Family(@F1@) = %v", f)
+	}
+	if s := x.Source("@S1@"); s != g.Source[0] {
+		t.Errorf("Source(@S1@) = %v", s)
+	}
+	if n := x.Note("@N1@"); n != g.Note[0] {
+		t.Errorf("Note(@N1@) = %v", n)
+	}
+	if i := x.Individual("@F1@"); i != nil {
+		t.Errorf("Individual(@F1@) = %v, expected nil", i)
+	}
+
+	examples := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{"BySurname", xrefs(x.BySurname("SMITH")), []string{"@I1@", "@I2@"}},
+		{"BySurname second name", xrefs(x.BySurname("jones")), []string{"@I2@"}},
+		{"ByFSFTID", xrefs(x.ByFSFTID("LZ4X-ABC")), []string{"@I1@"}},
+		{"ByUID", recordXrefs(x.ByUID("1234-ABCD")), []string{"@I1@"}},
+		{"ByRIN", recordXrefs(x.ByRIN("17")), []string{"@I1@", "@F1@"}},
+		{"ByREFN", recordXrefs(x.ByREFN("R-1")), []string{"@I1@", "@S1@"}},
+	}
+	for _, ex := range examples {
+		if diff := deep.Equal(ex.got, ex.want); diff != nil {
+			t.Errorf("%s: %v", ex.name, diff)
+		}
+	}
+}
+
+func TestIndexAddRemove(t *testing.T) {
+	g := &RootRecord{}
+	x := NewIndex(g)
+
+	i1 := &IndividualRecord{Xref: "@I1@", Name: NameRecords{{Name: "Ann /Brown/"}}, Rin: []string{"5"}}
+	i2 := &IndividualRecord{Xref: "@I1@"}
+	for _, rec := range []Record{i1, i2} {
+		if err := x.Add(rec); err != nil {
+			t.Fatalf("Add returned error %v", err)
+		}
+	}
+	if len(g.Individual) != 2 {
+		t.Errorf("Add: %d individuals, expected 2", len(g.Individual))
+	}
+	if i := x.Individual("@I1@"); i != i1 {
+		t.Errorf("Individual(@I1@) after Add is not the first definition")
+	}
+
+	if err := x.Remove(i1); err != nil {
+		t.Fatalf("Remove returned error %v", err)
+	}
+	if i := x.Individual("@I1@"); i != i2 {
+		t.Errorf("Individual(@I1@) after Remove is not the duplicate")
+	}
+	if got := x.BySurname("Brown"); len(got) != 0 {
+		t.Errorf("BySurname after Remove = %v", xrefs(got))
+	}
+	if got := x.ByRIN("5"); len(got) != 0 {
+		t.Errorf("ByRIN after Remove = %v", recordXrefs(got))
+	}
+	if err := x.Remove(i1); err == nil {
+		t.Errorf("Remove of a removed record returned no error")
+	}
+
+	i2.Xref = "@I2@"
+	i2.UniqueId_ = []string{"U2"}
+	x.Update(i2)
+	if i := x.Individual("@I2@"); i != i2 {
+		t.Errorf("Individual(@I2@) after Update = %v", i)
+	}
+	if i := x.Individual("@I1@"); i != nil {
+		t.Errorf("Individual(@I1@) after Update = %v, expected nil", i)
+	}
+	if got := recordXrefs(x.ByUID("U2")); len(got) != 1 {
+		t.Errorf("ByUID after Update = %v", got)
+	}
+
+	if err := x.Add(&HeaderRecord{}); err == nil {
+		t.Errorf("Add of a header returned no error")
+	}
+
+	// changing a result does not change the Index
+	got := x.ByUID("U2")
+	got[0] = nil
+	if got := recordXrefs(x.ByUID("U2")); len(got) != 1 || got[0] != "@I2@" {
+		t.Errorf("ByUID after changing its result = %v", got)
+	}
+
+	for _, rec := range []Record{&FamilyRecord{Xref: "@F9@"}, &NoteRecord{Xref: "@N9@"}, &EventRecord{Xref: "@E9@"}} {
+		if err := x.Add(rec); err != nil {
+			t.Fatalf("Add returned error %v", err)
+		}
+		if x.Record(keysOf(rec).xref) != rec {
+			t.Errorf("Record(%s) after Add = %v", keysOf(rec).xref, x.Record(keysOf(rec).xref))
+		}
+		if err := x.Remove(rec); err != nil {
+			t.Fatalf("Remove returned error %v", err)
+		}
+	}
+	if len(g.Family) != 0 || len(g.Note) != 0 || len(g.Event) != 0 {
+		t.Errorf("Remove left %d families, %d notes and %d events, expected none", len(g.Family), len(g.Note), len(g.Event))
+	}
+}
+
+// recordXrefs returns the xrefs of records, for comparing
+func recordXrefs(recs []Record) []string {
+	var ss []string
+	for _, rec := range recs {
+		ss = append(ss, keysOf(rec).xref)
+	}
+	return ss
+}
@@ -0,0 +1,267 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"reflect"
+	"sort"
+)
+
+// XrefSite represents a line which defines or points to an xref
+type XrefSite struct {
+	Xref   string // the xref, with its @s
+	Line   int    // line number, starting at 1
+	Tag    string // tag of the line
+	Record string // xref_id of the enclosing level 0 record
+}
+
+// xrefTable records the lines which define or point to xrefs
+type xrefTable struct {
+	defs []*XrefSite // level 0 lines with an xref_id
+	uses []*XrefSite // lines with a pointer value
+}
+
+// add records a line of the level 0 record with xref_id record if it
+// defines or points to an xref. The pointer may end a ROLE value, as in
+// ROLE (Witness) @I1@.
+func (t *xrefTable) add(line int, level int, tag string, value string, xref string, record string) {
+	if level == 0 && xref != "" {
+		t.defs = append(t.defs, &XrefSite{Xref: xref, Line: line, Tag: tag, Record: xref})
+	}
+	pointer := ""
+	switch {
+	case tag == "CONT" || tag == "CONC":
+	case isPointer(value):
+		pointer = value
+	case tag == "ROLE":
+		pointer = stripXref(value)
+	}
+	if pointer != "" {
+		t.uses = append(t.uses, &XrefSite{Xref: pointer, Line: line, Tag: tag, Record: record})
+	}
+}
+
+// track records a line which defines or points to an xref
+func (d *Decoder) track(level int, tag string, value string, xref string) {
+	if d.xrefs == nil {
+		d.xrefs = &xrefTable{}
+	}
+	d.xrefs.add(d.LineNum, level, tag, value, xref, d.xref)
+}
+
+// IntegrityReport lists the problems with the xrefs of a RootRecord
+type IntegrityReport struct {
+	Dangling     []*XrefSite // pointers to xrefs which are not defined
+	Duplicates   []*XrefSite // definitions of xrefs defined on an earlier line
+	Unreferenced []*XrefSite // definitions of xrefs which nothing points to
+}
+
+// OK reports whether no problems were found
+func (r *IntegrityReport) OK() bool {
+	return len(r.Dangling) == 0 && len(r.Duplicates) == 0 && len(r.Unreferenced) == 0
+}
+
+// Integrity checks the xrefs of the RootRecord as it is when called. It
+// finds every pointer to an xref which is not defined, every xref defined
+// more than once and every record which nothing points to. Sites read by
+// Decode have their line number in the input; sites added since, or in a
+// RootRecord which was not decoded, have Line 0.
+func (r *RootRecord) Integrity() *IntegrityReport {
+	read := r.xrefs
+	if read == nil {
+		read = &xrefTable{}
+	}
+	defs := make(map[string][]*XrefSite)    // definitions read, by xref
+	uses := make(map[[2]string][]*XrefSite) // pointers read, by record and xref
+	for _, site := range read.defs {
+		defs[site.Xref] = append(defs[site.Xref], site)
+	}
+	for _, site := range read.uses {
+		key := [2]string{site.Record, site.Xref}
+		uses[key] = append(uses[key], site)
+	}
+
+	recs := r.records()
+	visited := make(map[uintptr]bool)
+	for _, rec := range recs {
+		visited[reflect.ValueOf(rec).Pointer()] = true
+	}
+
+	t := &xrefTable{}
+	use := func(tag string, pointer string, record string) {
+		site := &XrefSite{Xref: pointer, Tag: tag, Record: record}
+		key := [2]string{record, pointer}
+		for i, read := range uses[key] {
+			if read.Tag == tag || tag == "" {
+				found := *read
+				site = &found
+				uses[key] = append(uses[key][:i:i], uses[key][i+1:]...)
+				break
+			}
+		}
+		t.uses = append(t.uses, site)
+	}
+	// links records the pointers in v, part of the record with xref_id record
+	links := func(v reflect.Value, record string) {
+		walk(v, visited, func(x interface{}) {
+			if tag, pointer := linkXref(x); pointer != "" {
+				use(tag, pointer, record)
+			}
+		})
+	}
+
+	if r.Header != nil {
+		links(reflect.ValueOf(r.Header).Elem(), "")
+	}
+	walked := make(map[Record]bool)
+	for _, rec := range recs {
+		xref := keysOf(rec).xref
+		if xref != "" {
+			site := &XrefSite{Xref: xref, Tag: recordTag(rec), Record: xref}
+			if sites := defs[xref]; len(sites) > 0 {
+				found := *sites[0]
+				site, defs[xref] = &found, sites[1:]
+			}
+			t.defs = append(t.defs, site)
+		}
+		if walked[rec] {
+			continue // a record defined twice is one record in the tree
+		}
+		walked[rec] = true
+
+		links(reflect.ValueOf(rec).Elem(), xref)
+		if subn, ok := rec.(*SubmissionRecord); ok && subn.Submitter != nil && subn.Submitter.Xref != "" {
+			use("SUBM", subn.Submitter.Xref, xref)
+		}
+	}
+
+	// the lines read come first, in input order
+	for _, sites := range [][]*XrefSite{t.defs, t.uses} {
+		sort.SliceStable(sites, func(i, j int) bool {
+			return sites[j].Line == 0 && sites[i].Line != 0 || sites[i].Line != 0 && sites[i].Line < sites[j].Line
+		})
+	}
+	return t.report()
+}
+
+// recordTag returns the tag of a level 0 record
+func recordTag(rec Record) string {
+	switch x := rec.(type) {
+	case *IndividualRecord:
+		return "INDI"
+	case *FamilyRecord:
+		return "FAM"
+	case *SourceRecord:
+		return "SOUR"
+	case *NoteRecord:
+		return "NOTE"
+	case *MediaRecord:
+		return x.Tag
+	case *RepositoryRecord:
+		return "REPO"
+	case *SubmitterRecord:
+		return "SUBM"
+	case *SubmissionRecord:
+		return "SUBN"
+	case *PlaceRecord:
+		return x.Tag
+	case *EventRecord:
+		return x.Tag
+	}
+	return ""
+}
+
+// linkXref returns the tag and the xref of a link found by walk, or an
+// empty xref if v is not a link
+func linkXref(v interface{}) (tag string, xref string) {
+	switch x := v.(type) {
+	case *FamilyLink:
+		if isPointer(x.Value) {
+			return x.Tag, x.Value
+		}
+	case *CitationRecord:
+		if isPointer(x.Value) {
+			return "SOUR", x.Value
+		}
+	case *NoteRecord:
+		if x.Xref == "" && isPointer(x.Note) {
+			return "NOTE", x.Note
+		}
+	case *IndividualLink:
+		if x.Individual != nil {
+			return x.Tag, x.Individual.Xref
+		}
+	case *RoleRecord:
+		if x.Individual != nil {
+			return "ROLE", x.Individual.Xref
+		}
+	case *MediaLink:
+		switch {
+		case isPointer(x.Value):
+			return x.Tag, x.Value
+		case x.Media != nil && x.Media.Level == 0 && x.Media.Xref != "":
+			return x.Tag, x.Media.Xref
+		}
+	case *AttributeRecord:
+		if x.Xref == "" && isPointer(x.Value) {
+			return x.Tag, x.Value
+		}
+	case *EventRecord:
+		if x.Xref == "" && isPointer(x.Value) {
+			return x.Tag, x.Value
+		}
+	case *PlaceRecord:
+		if x.Xref == "" && isPointer(x.Name) {
+			return x.Tag, x.Name
+		}
+	case *RepositoryLink:
+		if x.Repository != nil {
+			return "REPO", x.Repository.Xref
+		}
+	case *SubmitterLink:
+		if x.Submitter != nil {
+			return x.Tag, x.Submitter.Xref
+		}
+	case *SubmissionLink:
+		if x.Submission != nil {
+			return "SUBN", x.Submission.Xref
+		}
+	}
+	return "", ""
+}
+
+// report returns the problems with the xrefs in t
+func (t *xrefTable) report() *IntegrityReport {
+	report := &IntegrityReport{}
+
+	defined := make(map[string]bool)
+	for _, site := range t.defs {
+		if defined[site.Xref] {
+			report.Duplicates = append(report.Duplicates, site)
+		}
+		defined[site.Xref] = true
+	}
+
+	used := make(map[string]bool)
+	for _, site := range t.uses {
+		if !defined[site.Xref] {
+			report.Dangling = append(report.Dangling, site)
+		}
+		if site.Xref != site.Record {
+			used[site.Xref] = true
+		}
+	}
+
+	reported := make(map[string]bool)
+	for _, site := range t.defs {
+		if !used[site.Xref] && !reported[site.Xref] {
+			report.Unreferenced = append(report.Unreferenced, site)
+			reported[site.Xref] = true
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,92 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestIntegrity(t *testing.T) {
+	input := `0 HEAD
+0 @I1@ INDI
+1 NAME John /Smith/
+1 FAMS @F1@
+1 SOUR @S1@
+0 @I2@ INDI
+1 NOTE Mail to jsmith@@example.com
+0 @F1@ FAM
+1 HUSB @I1@
+1 WIFE @I999@
+1 CHIL @I2@
+1 MARR
+2 ROLE (Witness) @I3@
+0 @S1@ SOUR
+0 @I2@ INDI
+0 @N1@ NOTE Never used
+0 @I3@ INDI
+0 TRLR
+`
+	d := NewDecoder(bytes.NewReader([]byte(input)))
+	g, err := d.Decode()
+	if err != nil {
+		t.Fatalf("Decode returned error %v", err)
+	}
+
+	want := &IntegrityReport{
+		Dangling:     []*XrefSite{{Xref: "@I999@", Line: 10, Tag: "WIFE", Record: "@F1@"}},
+		Duplicates:   []*XrefSite{{Xref: "@I2@", Line: 15, Tag: "INDI", Record: "@I2@"}},
+		Unreferenced: []*XrefSite{{Xref: "@N1@", Line: 16, Tag: "NOTE", Record: "@N1@"}},
+	}
+	report := g.Integrity()
+	if diff := deep.Equal(report, want); diff != nil {
+		t.Errorf("Integrity: %v", diff)
+	}
+	if report.OK() {
+		t.Errorf("Integrity().OK() = true, expected false")
+	}
+
+	defer func(n int) { chunkLines = n }(chunkLines)
+	chunkLines = 2
+	g2, err := NewDecoderWithOptions(bytes.NewReader([]byte(input)), DecoderOptions{Workers: 4}).Decode()
+	if err != nil {
+		t.Fatalf("Decode with workers returned error %v", err)
+	}
+	if diff := deep.Equal(g2.Integrity(), want); diff != nil {
+		t.Errorf("Integrity with workers: %v", diff)
+	}
+
+	// the report follows changes to the tree, keeping the input lines
+	g.Individual = g.Individual[:1]
+	g.Note = nil
+	g.Family[0].Child = append(g.Family[0].Child, &IndividualLink{Level: 1, Tag: "CHIL", Individual: &IndividualRecord{Xref: "@I4@"}})
+	want = &IntegrityReport{
+		Dangling: []*XrefSite{
+			{Xref: "@I999@", Line: 10, Tag: "WIFE", Record: "@F1@"},
+			{Xref: "@I2@", Line: 11, Tag: "CHIL", Record: "@F1@"},
+			{Xref: "@I3@", Line: 13, Tag: "ROLE", Record: "@F1@"},
+			{Xref: "@I4@", Line: 0, Tag: "CHIL", Record: "@F1@"},
+		},
+	}
+	if diff := deep.Equal(g.Integrity(), want); diff != nil {
+		t.Errorf("Integrity after removing records: %v", diff)
+	}
+
+	built := &RootRecord{
+		Individual: IndividualRecords{{Xref: "@I1@", Parents: FamilyLinks{{Level: 1, Tag: "FAMC", Value: "@F1@"}}}},
+		Family:     FamilyRecords{{Xref: "@F1@"}},
+	}
+	want = &IntegrityReport{Unreferenced: []*XrefSite{{Xref: "@I1@", Line: 0, Tag: "INDI", Record: "@I1@"}}}
+	if diff := deep.Equal(built.Integrity(), want); diff != nil {
+		t.Errorf("Integrity of a built RootRecord: %v", diff)
+	}
+
+	if report := (&RootRecord{}).Integrity(); !report.OK() {
+		t.Errorf("Integrity() of an empty RootRecord = %v, expected no problems", report)
+	}
+}
@@ -0,0 +1,176 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"strings"
+)
+
+// ParsedName represents the pieces of a NAME value like "Dr. John /Kennedy/ Jr."
+type ParsedName struct {
+	Prefix        string // title before the given names, like Dr.
+	Given         string // given names
+	SurnamePrefix string // lower case particles before the surname, like van der
+	Surname       string // surname, between the slashes
+	Suffix        string // text after the surname, like Jr.
+}
+
+// namePrefixes are the titles recognised before the given names
+var namePrefixes = map[string]bool{
+	"capt": true, "col": true, "dame": true, "dr": true, "fr": true,
+	"gen": true, "hon": true, "lady": true, "lord": true, "lt": true,
+	"miss": true, "mr": true, "mrs": true, "ms": true, "prof": true,
+	"rev": true, "sgt": true, "sir": true,
+}
+
+// nameSuffixes are the suffixes recognised after the given names of a
+// NAME value without a surname, or after the surname of a value which
+// starts with it
+var nameSuffixes = map[string]bool{
+	"jr": true, "sr": true, "ii": true, "iii": true, "iv": true, "v": true,
+	"esq": true, "phd": true, "md": true,
+}
+
+// surnamePrefixes are the particles recognised before a surname when they
+// are written in lower case
+var surnamePrefixes = map[string]bool{
+	"d'": true, "da": true, "das": true, "de": true, "del": true,
+	"della": true, "den": true, "der": true, "des": true, "di": true,
+	"do": true, "dos": true, "du": true, "la": true, "le": true,
+	"ten": true, "ter": true, "van": true, "von": true, "zu": true,
+}
+
+// ParseName splits a NAME value into its pieces. The surname is the text
+// between the first pair of slashes; without slashes the whole value is
+// given names.
+func ParseName(value string) *ParsedName {
+	p := &ParsedName{}
+
+	before, surname, after := value, "", ""
+	if i := strings.Index(value, "/"); i >= 0 {
+		before = value[:i]
+		rest := value[i+1:]
+		if j := strings.Index(rest, "/"); j >= 0 {
+			surname, after = rest[:j], rest[j+1:]
+		} else {
+			surname = rest
+		}
+	}
+
+	given := strings.Fields(before)
+	for len(given) > 0 && namePrefixes[nameWord(given[0])] {
+		p.Prefix = joinName(p.Prefix, given[0])
+		given = given[1:]
+	}
+
+	suffix := strings.Fields(strings.TrimLeft(strings.TrimSpace(after), ","))
+	switch {
+	case surname == "" && after == "":
+		// suffixes may follow the given names when there is no surname
+		n := len(given)
+		for n > 1 && nameSuffixes[nameWord(given[n-1])] {
+			n--
+		}
+		suffix = given[n:]
+		given = given[:n]
+		if n > 0 {
+			given[n-1] = strings.TrimSuffix(given[n-1], ",")
+		}
+	case len(given) == 0:
+		// the given names may follow the surname
+		n := 0
+		for n < len(suffix) && !nameSuffixes[nameWord(suffix[n])] {
+			n++
+		}
+		given = suffix[:n]
+		suffix = suffix[n:]
+	}
+	p.Given = strings.Join(given, " ")
+	p.Suffix = strings.Join(suffix, " ")
+
+	words := strings.Fields(surname)
+	n := 0
+	for n < len(words)-1 && surnamePrefixes[words[n]] {
+		n++
+	}
+	p.SurnamePrefix = strings.Join(words[:n], " ")
+	p.Surname = strings.Join(words[n:], " ")
+
+	return p
+}
+
+// nameWord returns a word of a name in lower case without punctuation,
+// for looking up prefixes and suffixes
+func nameWord(s string) string {
+	return strings.ToLower(strings.Trim(s, ".,"))
+}
+
+// joinName joins pieces of a name with a space, skipping empty pieces
+func joinName(ss ...string) string {
+	var parts []string
+	for _, s := range ss {
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Parse splits the NAME value of the record into its pieces, preferring
+// the NPFX, GIVN, SPFX, SURN and NSFX values where they are present
+func (r *NameRecord) Parse() *ParsedName {
+	p := ParseName(r.Name)
+	if r.Prefix != "" {
+		p.Prefix = r.Prefix
+	}
+	if r.GivenName != "" {
+		p.Given = r.GivenName
+	}
+	if r.SurnamePrefix != "" {
+		p.SurnamePrefix = r.SurnamePrefix
+	}
+	if r.Surname != "" {
+		p.Surname = r.Surname
+	}
+	if r.Suffix != "" {
+		p.Suffix = r.Suffix
+	}
+	return p
+}
+
+// Fill sets the Prefix, GivenName, SurnamePrefix, Surname and Suffix of the
+// record which are empty from the pieces of the NAME value
+func (r *NameRecord) Fill() {
+	p := r.Parse()
+	r.Prefix = p.Prefix
+	r.GivenName = p.Given
+	r.SurnamePrefix = p.SurnamePrefix
+	r.Surname = p.Surname
+	r.Suffix = p.Suffix
+}
+
+// NameFormat selects the order of the pieces of a displayed name
+type NameFormat int
+
+const (
+	GivenSurname NameFormat = iota // "Dr. John van der Berg Jr."
+	SurnameGiven                   // "van der Berg, Dr. John Jr."
+)
+
+// Display formats the name for reports
+func (r *NameRecord) Display(format NameFormat) string {
+	p := r.Parse()
+	given := joinName(p.Prefix, p.Given, r.MiddleName_)
+	surname := joinName(p.SurnamePrefix, p.Surname)
+
+	if format == SurnameGiven && surname != "" {
+		if given == "" && p.Suffix == "" {
+			return surname
+		}
+		return surname + ", " + joinName(given, p.Suffix)
+	}
+	return joinName(given, surname, p.Suffix)
+}
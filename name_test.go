@@ -0,0 +1,89 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+var nameExamples = []struct {
+	input string
+	want  ParsedName
+}{
+	{"John /Kennedy/", ParsedName{Given: "John", Surname: "Kennedy"}},
+	{"John Fitzgerald /Kennedy/ Jr.", ParsedName{Given: "John Fitzgerald", Surname: "Kennedy", Suffix: "Jr."}},
+	{"Dr. Jan /van der Berg/", ParsedName{Prefix: "Dr.", Given: "Jan", SurnamePrefix: "van der", Surname: "Berg"}},
+	{"Martin /Van Buren/", ParsedName{Given: "Martin", Surname: "Van Buren"}},
+	{"/Kennedy/", ParsedName{Surname: "Kennedy"}},
+	{"/Kennedy/ John", ParsedName{Given: "John", Surname: "Kennedy"}},
+	{"John /Kennedy", ParsedName{Given: "John", Surname: "Kennedy"}},
+	{"John Smith, Jr.", ParsedName{Given: "John Smith", Suffix: "Jr."}},
+	{"Adopted Twin", ParsedName{Given: "Adopted Twin"}},
+	{"", ParsedName{}},
+}
+
+func TestParseName(t *testing.T) {
+	for _, ex := range nameExamples {
+		p := ParseName(ex.input)
+		if diff := deep.Equal(*p, ex.want); diff != nil {
+			t.Errorf("ParseName(%q): %v", ex.input, diff)
+		}
+	}
+}
+
+func TestNameDisplay(t *testing.T) {
+	examples := []struct {
+		name         NameRecord
+		givenSurname string
+		surnameGiven string
+	}{
+		{NameRecord{Name: "John Fitzgerald /Kennedy/ Jr."}, "John Fitzgerald Kennedy Jr.", "Kennedy, John Fitzgerald Jr."},
+		{NameRecord{Name: "Dr. Jan /van der Berg/"}, "Dr. Jan van der Berg", "van der Berg, Dr. Jan"},
+		{NameRecord{Name: "/Kennedy/"}, "Kennedy", "Kennedy"},
+		{NameRecord{Name: "Adopted"}, "Adopted", "Adopted"},
+		{NameRecord{Name: "Jack /Kennedy/", GivenName: "John"}, "John Kennedy", "Kennedy, John"},
+	}
+
+	for _, ex := range examples {
+		if s := ex.name.Display(GivenSurname); s != ex.givenSurname {
+			t.Errorf("Display(GivenSurname) of %q = %q, expected %q", ex.name.Name, s, ex.givenSurname)
+		}
+		if s := ex.name.Display(SurnameGiven); s != ex.surnameGiven {
+			t.Errorf("Display(SurnameGiven) of %q = %q, expected %q", ex.name.Name, s, ex.surnameGiven)
+		}
+	}
+}
+
+func TestFillNames(t *testing.T) {
+	input := "0 HEAD\n0 @I1@ INDI\n1 NAME Dr. John /Kennedy/ Jr.\n2 GIVN Jack\n0 TRLR\n"
+
+	d := NewDecoderWithOptions(bytes.NewReader([]byte(input)), DecoderOptions{FillNames: true})
+	g, err := d.Decode()
+	if err != nil {
+		t.Fatalf("Decode returned error %v", err)
+	}
+
+	want := NameRecord{Level: 1, Name: "Dr. John /Kennedy/ Jr.", Prefix: "Dr.", GivenName: "Jack", Surname: "Kennedy", Suffix: "Jr."}
+	if diff := deep.Equal(*g.Individual[0].Name[0], want); diff != nil {
+		t.Errorf("FillNames: %v", diff)
+	}
+
+	// without FillNames only the values in the file are set
+	g, err = NewDecoder(bytes.NewReader([]byte(input))).Decode()
+	if err != nil {
+		t.Fatalf("Decode returned error %v", err)
+	}
+	want = NameRecord{Level: 1, Name: "Dr. John /Kennedy/ Jr.", GivenName: "Jack"}
+	if diff := deep.Equal(*g.Individual[0].Name[0], want); diff != nil {
+		t.Errorf("without FillNames: %v", diff)
+	}
+	if s := (&NameRecord{Name: "John /Kennedy/ Jr."}).Display(SurnameGiven); s != "Kennedy, John Jr." {
+		t.Errorf("Display(SurnameGiven) = %q, expected %q", s, "Kennedy, John Jr.")
+	}
+}
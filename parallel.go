@@ -0,0 +1,266 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// chunkLines is the number of lines after which a chunk is ended at the
+// next level 0 line
+var chunkLines = 4096
+
+// chunkLine is a line of a chunk with its position in the input
+type chunkLine struct {
+	line
+	num    int   // line number
+	offset int64 // byte offset of the start of the line
+}
+
+// chunk is a run of level 0 records which a worker parses with its own
+// Decoder into its own RootRecord
+type chunk struct {
+	lines  []chunkLine
+	d      *Decoder
+	root   *RootRecord
+	err    error         // error which stopped parsing
+	parsed chan struct{} // closed when the chunk has been parsed
+}
+
+// parse parses the lines of the chunk and releases them
+func (c *chunk) parse() {
+	defer close(c.parsed)
+	for _, l := range c.lines {
+		c.d.LineNum = l.num
+		c.d.offset = l.offset
+		if c.err = c.d.parseLine(l.line, true); c.err != nil {
+			break
+		}
+	}
+	c.lines = nil
+}
+
+// scanParallel reads the input into chunks of level 0 records, parses
+// them with d.opts.Workers workers and merges each into r in input order
+// once it has been parsed
+func (d *Decoder) scanParallel(ctx context.Context, r *RootRecord) error {
+	jobs := make(chan *chunk, d.opts.Workers)
+	var wg sync.WaitGroup
+	for i := 0; i < d.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range jobs {
+				c.parse()
+			}
+		}()
+	}
+
+	// the merger only touches r, d.refs and d.xrefs, which scanning
+	// leaves alone; its problems are added to d.Errors at the end
+	pending := make(chan *chunk, 2*d.opts.Workers)
+	m := &merger{d: d, r: r, defined: make(map[string]bool)}
+	merged := make(chan struct{})
+	go func() {
+		defer close(merged)
+		for c := range pending {
+			<-c.parsed
+			m.merge(c)
+		}
+	}()
+
+	var cur *chunk
+	send := func() {
+		if cur != nil {
+			pending <- cur
+			jobs <- cur
+			cur = nil
+		}
+	}
+	err := d.scan(ctx, func(l line) error {
+		if l.level == 0 {
+			if cur != nil && len(cur.lines) >= chunkLines {
+				send()
+			}
+			d.xref = l.xref // for problems found by nextLine
+		}
+		if cur == nil {
+			cur = d.newChunk()
+		}
+		cur.lines = append(cur.lines, chunkLine{line: l, num: d.LineNum, offset: d.offset})
+		return nil
+	})
+	send()
+	close(jobs)
+	close(pending)
+	wg.Wait()
+	<-merged
+
+	// problems found while reading a line come before those found while
+	// parsing it
+	d.Errors = append(d.Errors, m.errors...)
+	sort.SliceStable(d.Errors, func(i, j int) bool {
+		return d.Errors[i].Line < d.Errors[j].Line
+	})
+	if m.xref != "" {
+		d.xref = m.xref
+	}
+	if err == nil {
+		err = m.err
+	}
+	return err
+}
+
+// newChunk returns a chunk with a Decoder like d
+func (d *Decoder) newChunk() *chunk {
+	c := &chunk{root: &RootRecord{Level: -1}, parsed: make(chan struct{})}
+	c.d = &Decoder{
+		refs:    make(map[string]interface{}),
+		opts:    d.opts,
+		charset: d.charset,
+	}
+	c.d.parsers = []parser{makeRootParser(c.d, c.root)}
+	return c
+}
+
+// merger merges parsed chunks into a RootRecord and its Decoder, as if
+// they had been decoded in order by the Decoder
+type merger struct {
+	d       *Decoder
+	r       *RootRecord
+	known   int             // known level 0 lines in earlier chunks
+	defined map[string]bool // xrefs defined by earlier chunks
+	errors  []*DecodeError  // problems found by the chunks
+	xref    string          // xref of the last level 0 line parsed
+	err     error           // first error which stopped a chunk
+}
+
+// merge appends the records, xrefs and problems of the next chunk
+func (m *merger) merge(c *chunk) {
+	d, r, cr := m.d, m.r, c.root
+
+	// a record defined again takes the lines of the new definition, and
+	// stays in the place of each definition, as the Decoder does
+	local := make(map[string]bool)
+	if c.d.xrefs != nil {
+		for _, site := range c.d.xrefs.defs {
+			local[site.Xref] = true
+		}
+	}
+	same := make(map[interface{}]interface{})
+	for xref := range local {
+		prev, rec := d.refs[xref], c.d.refs[xref]
+		if m.defined[xref] && prev != nil && reflect.TypeOf(prev) == reflect.TypeOf(rec) {
+			mergeRecord(reflect.ValueOf(prev).Elem(), reflect.ValueOf(rec).Elem())
+			same[rec] = prev
+		}
+	}
+	if len(same) > 0 {
+		replaceRecords(reflect.ValueOf(cr).Elem(), same)
+	}
+
+	if cr.Header != nil {
+		r.Header = cr.Header
+	}
+	r.Publish_ = append(r.Publish_, cr.Publish_...)
+	r.Submitter = append(r.Submitter, cr.Submitter...)
+	r.Submission = append(r.Submission, cr.Submission...)
+	r.Place = append(r.Place, cr.Place...)
+	r.Event = append(r.Event, cr.Event...)
+	r.Individual = append(r.Individual, cr.Individual...)
+	r.Family = append(r.Family, cr.Family...)
+	r.Media = append(r.Media, cr.Media...)
+	r.Note = append(r.Note, cr.Note...)
+	r.PlaceDefinition_ = append(r.PlaceDefinition_, cr.PlaceDefinition_...)
+	r.EventDefinition_ = append(r.EventDefinition_, cr.EventDefinition_...)
+	r.ChildStatus = append(r.ChildStatus, cr.ChildStatus...)
+	r.Todo_ = append(r.Todo_, cr.Todo_...)
+	r.Source = append(r.Source, cr.Source...)
+	r.Repository = append(r.Repository, cr.Repository...)
+	r.Album = append(r.Album, cr.Album...)
+	// unknown level 0 lines are placed among the records of all chunks
+	for _, raw := range cr.UnknownTags {
+		if raw.Level == 0 && raw.Position > 0 {
+			raw.Position += m.known
+		}
+	}
+	if len(c.d.known) > 0 {
+		m.known += c.d.known[0]
+	}
+	r.UnknownTags = append(r.UnknownTags, cr.UnknownTags...)
+	if cr.Trailer != nil {
+		r.Trailer = cr.Trailer
+	}
+	if cr.Level == 0 {
+		r.Level = 0
+	}
+
+	// records defined in the chunk take the place of links to them
+	// from earlier chunks; Resolve links the rest
+	for xref, rec := range c.d.refs {
+		if _, found := d.refs[xref]; !found || (local[xref] && !m.defined[xref]) {
+			d.refs[xref] = rec
+		}
+	}
+	for xref := range local {
+		m.defined[xref] = true
+	}
+	if c.d.xrefs != nil {
+		if d.xrefs == nil {
+			d.xrefs = &xrefTable{}
+		}
+		d.xrefs.defs = append(d.xrefs.defs, c.d.xrefs.defs...)
+		d.xrefs.uses = append(d.xrefs.uses, c.d.xrefs.uses...)
+	}
+
+	m.errors = append(m.errors, c.d.Errors...)
+	if c.d.xref != "" {
+		m.xref = c.d.xref
+	}
+	if m.err == nil {
+		m.err = c.err
+	}
+}
+
+// mergeRecord sets the exported fields of dst from src as parsing the
+// lines of src after those of dst would: lists are appended to, and
+// other fields are replaced when src sets them
+func mergeRecord(dst, src reflect.Value) {
+	for i := 0; i < dst.NumField(); i++ {
+		f := dst.Field(i)
+		if !f.CanSet() {
+			continue
+		}
+		v := src.Field(i)
+		switch {
+		case f.Kind() == reflect.Slice:
+			if v.Len() > 0 {
+				f.Set(reflect.AppendSlice(f, v))
+			}
+		case !v.IsZero():
+			f.Set(v)
+		}
+	}
+}
+
+// replaceRecords replaces the records in the lists of a RootRecord which
+// are keys of same with their values
+func replaceRecords(root reflect.Value, same map[interface{}]interface{}) {
+	for i := 0; i < root.NumField(); i++ {
+		f := root.Field(i)
+		if f.Kind() != reflect.Slice || f.Type().Elem().Kind() != reflect.Ptr || !f.CanSet() {
+			continue
+		}
+		for j := 0; j < f.Len(); j++ {
+			if prev, found := same[f.Index(j).Interface()]; found {
+				f.Index(j).Set(reflect.ValueOf(prev))
+			}
+		}
+	}
+}
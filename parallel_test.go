@@ -0,0 +1,59 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestDecodeParallel(t *testing.T) {
+	defer func(n int) { chunkLines = n }(chunkLines)
+	chunkLines = 10
+
+	inputs := map[string][]byte{
+		"redefined": []byte("0 HEAD\n1 CHAR UTF-8\n0 @I1@ INDI\n1 NAME A /B/\n1 FAMS @F1@\n" +
+			strings.Repeat("0 @N1@ NOTE x\n1 CONC y\n", 6) +
+			"0 @F1@ FAM\n1 HUSB @I1@\n1 _X jump\n3 DATE 1900\n0 @I1@ INDI\n1 SEX M\n0 TRLR\n"),
+	}
+	for _, name := range []string{"testdata/allged.ged", "testdata/kennedy.ged"} {
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatalf("Cannot read %s: %v", name, err)
+		}
+		inputs[name] = data
+	}
+
+	for name, data := range inputs {
+		d1 := NewDecoder(bytes.NewReader(data))
+		g1, err1 := d1.Decode()
+		d2 := NewDecoderWithOptions(bytes.NewReader(data), DecoderOptions{Workers: 4})
+		g2, err2 := d2.Decode()
+		if err1 != nil || err2 != nil {
+			t.Fatalf("%s: Decode returned %v and %v", name, err1, err2)
+		}
+
+		if !reflect.DeepEqual(g1, g2) {
+			t.Errorf("%s: parallel Decode differs: %v", name, deep.Equal(g1, g2))
+		}
+		if g1.String() != g2.String() {
+			t.Errorf("%s: parallel Decode writes differently", name)
+		}
+		if diff := deep.Equal(d1.Errors, d2.Errors); diff != nil {
+			t.Errorf("%s: parallel Decode errors: %v", name, diff)
+		}
+		for _, fam := range g2.Family {
+			if d2.FindFamily(fam.Xref) != fam {
+				t.Errorf("%s: FindFamily(%s) is not the decoded family", name, fam.Xref)
+			}
+		}
+	}
+}
@@ -0,0 +1,849 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"strings"
+	"unicode"
+)
+
+// PhoneticAlgorithm selects how names are compared by NameSearch
+type PhoneticAlgorithm int
+
+// Phonetic algorithms
+const (
+	Soundex         PhoneticAlgorithm = iota // American Soundex
+	DaitchMokotoff                           // Daitch-Mokotoff Soundex
+	DoubleMetaphone                          // Double Metaphone
+)
+
+// String returns the name of the algorithm
+func (a PhoneticAlgorithm) String() string {
+	switch a {
+	case Soundex:
+		return "Soundex"
+	case DaitchMokotoff:
+		return "Daitch-Mokotoff"
+	case DoubleMetaphone:
+		return "Double Metaphone"
+	}
+	return "unknown"
+}
+
+// foldedLetters maps letters with diacritics to ASCII letters
+var foldedLetters = map[rune]string{
+	'À': "A", 'Á': "A", 'Â': "A", 'Ã': "A", 'Ä': "A", 'Å': "A", 'Ą': "A",
+	'Æ': "AE", 'Ç': "C", 'Ć': "C", 'Č': "C", 'Ď': "D", 'Ð': "D",
+	'È': "E", 'É': "E", 'Ê': "E", 'Ë': "E", 'Ę': "E", 'Ě': "E",
+	'Ì': "I", 'Í': "I", 'Î': "I", 'Ï': "I", 'Ł': "L",
+	'Ñ': "N", 'Ń': "N", 'Ň': "N",
+	'Ò': "O", 'Ó': "O", 'Ô': "O", 'Õ': "O", 'Ö': "O", 'Ø': "O", 'Œ': "OE",
+	'Ř': "R", 'Ś': "S", 'Š': "S", 'ẞ': "SS", 'ß': "SS", 'Ť': "T", 'Þ': "TH",
+	'Ù': "U", 'Ú': "U", 'Û': "U", 'Ü': "U", 'Ů': "U",
+	'Ý': "Y", 'Ÿ': "Y", 'Ź': "Z", 'Ż': "Z", 'Ž': "Z",
+}
+
+// phoneticLetters returns a name in upper case ASCII letters, with
+// diacritics removed and other characters dropped, or with runs of them
+// replaced by a space if spaces is true
+func phoneticLetters(name string, spaces bool) string {
+	var b strings.Builder
+	space := false
+	for _, c := range strings.ToUpper(name) {
+		s := ""
+		switch {
+		case c >= 'A' && c <= 'Z':
+			s = string(c)
+		case foldedLetters[c] != "":
+			s = foldedLetters[c]
+		case unicode.IsLetter(c):
+			continue
+		default:
+			space = spaces && b.Len() > 0
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteString(s)
+	}
+	return b.String()
+}
+
+// soundexCodes are the digits of the letters A to Z; 0 for vowels, which
+// separate letters with the same digit, and - for H and W, which do not
+const soundexCodes = "0123012-02245501262301-202"
+
+// SoundexKey returns the American Soundex code of a name, like S530 for
+// Smith, or blank if the name has no letters
+func SoundexKey(name string) string {
+	s := phoneticLetters(name, false)
+	if s == "" {
+		return ""
+	}
+
+	key := []byte{s[0]}
+	last := soundexCodes[s[0]-'A']
+	for i := 1; i < len(s) && len(key) < 4; i++ {
+		code := soundexCodes[s[i]-'A']
+		switch {
+		case code == '-':
+			continue
+		case code != '0' && code != last:
+			key = append(key, code)
+		}
+		last = code
+	}
+	for len(key) < 4 {
+		key = append(key, '0')
+	}
+	return string(key)
+}
+
+// dmRule is a rule of Daitch-Mokotoff Soundex: the codes of a group of
+// letters at the start of a name, before a vowel and elsewhere. A code of
+// - codes nothing, and codes separated by | are alternatives.
+type dmRule struct {
+	letters string
+	start   string
+	vowel   string
+	other   string
+}
+
+// dmRules are the rules of Daitch-Mokotoff Soundex, the longest first
+// for each initial letter
+var dmRules = map[byte][]dmRule{
+	'A': {{"AI", "0", "1", "-"}, {"AJ", "0", "1", "-"}, {"AY", "0", "1", "-"}, {"AU", "0", "7", "-"}, {"A", "0", "-", "-"}},
+	'B': {{"B", "7", "7", "7"}},
+	'C': {
+		{"CHS", "5", "54", "54"}, {"CSZ", "4", "4", "4"}, {"CZS", "4", "4", "4"},
+		{"CH", "5|4", "5|4", "5|4"}, {"CK", "5|45", "5|45", "5|45"}, {"CZ", "4", "4", "4"}, {"CS", "4", "4", "4"},
+		{"C", "5|4", "5|4", "5|4"},
+	},
+	'D': {
+		{"DRZ", "4", "4", "4"}, {"DRS", "4", "4", "4"}, {"DSH", "4", "4", "4"}, {"DSZ", "4", "4", "4"},
+		{"DZH", "4", "4", "4"}, {"DZS", "4", "4", "4"},
+		{"DS", "4", "4", "4"}, {"DZ", "4", "4", "4"}, {"DT", "3", "3", "3"}, {"D", "3", "3", "3"},
+	},
+	'E': {{"EI", "0", "1", "-"}, {"EJ", "0", "1", "-"}, {"EY", "0", "1", "-"}, {"EU", "1", "1", "-"}, {"E", "0", "-", "-"}},
+	'F': {{"FB", "7", "7", "7"}, {"F", "7", "7", "7"}},
+	'G': {{"G", "5", "5", "5"}},
+	'H': {{"H", "5", "5", "-"}},
+	'I': {{"IA", "1", "-", "-"}, {"IE", "1", "-", "-"}, {"IO", "1", "-", "-"}, {"IU", "1", "-", "-"}, {"I", "0", "-", "-"}},
+	'J': {{"J", "1|4", "1|4", "1|4"}},
+	'K': {{"KS", "5", "54", "54"}, {"KH", "5", "5", "5"}, {"K", "5", "5", "5"}},
+	'L': {{"L", "8", "8", "8"}},
+	'M': {{"MN", "66", "66", "66"}, {"M", "6", "6", "6"}},
+	'N': {{"NM", "66", "66", "66"}, {"N", "6", "6", "6"}},
+	'O': {{"OI", "0", "1", "-"}, {"OJ", "0", "1", "-"}, {"OY", "0", "1", "-"}, {"O", "0", "-", "-"}},
+	'P': {{"PF", "7", "7", "7"}, {"PH", "7", "7", "7"}, {"P", "7", "7", "7"}},
+	'Q': {{"Q", "5", "5", "5"}},
+	'R': {{"RS", "94|4", "94|4", "94|4"}, {"RZ", "94|4", "94|4", "94|4"}, {"R", "9", "9", "9"}},
+	'S': {
+		{"SCHTSCH", "2", "4", "4"}, {"SCHTSH", "2", "4", "4"}, {"SCHTCH", "2", "4", "4"},
+		{"SHTCH", "2", "4", "4"}, {"SHTSH", "2", "4", "4"}, {"STSCH", "2", "4", "4"},
+		{"SCHT", "2", "43", "43"}, {"SCHD", "2", "43", "43"}, {"SHCH", "2", "4", "4"},
+		{"STCH", "2", "4", "4"}, {"STRZ", "2", "4", "4"}, {"STRS", "2", "4", "4"}, {"STSH", "2", "4", "4"},
+		{"SZCZ", "2", "4", "4"}, {"SZCS", "2", "4", "4"},
+		{"SCH", "4", "4", "4"}, {"SHT", "2", "43", "43"}, {"SZT", "2", "43", "43"}, {"SHD", "2", "43", "43"}, {"SZD", "2", "43", "43"},
+		{"SC", "2", "4", "4"}, {"SD", "2", "43", "43"}, {"SH", "4", "4", "4"}, {"ST", "2", "43", "43"}, {"SZ", "4", "4", "4"},
+		{"S", "4", "4", "4"},
+	},
+	'T': {
+		{"TTSCH", "4", "4", "4"}, {"TSCH", "4", "4", "4"}, {"TTCH", "4", "4", "4"}, {"TTSZ", "4", "4", "4"},
+		{"TCH", "4", "4", "4"}, {"TRZ", "4", "4", "4"}, {"TRS", "4", "4", "4"}, {"TSH", "4", "4", "4"},
+		{"TTS", "4", "4", "4"}, {"TTZ", "4", "4", "4"}, {"TZS", "4", "4", "4"}, {"TSZ", "4", "4", "4"},
+		{"TH", "3", "3", "3"}, {"TS", "4", "4", "4"}, {"TC", "4", "4", "4"}, {"TZ", "4", "4", "4"},
+		{"T", "3", "3", "3"},
+	},
+	'U': {{"UI", "0", "1", "-"}, {"UJ", "0", "1", "-"}, {"UY", "0", "1", "-"}, {"UE", "0", "-", "-"}, {"U", "0", "-", "-"}},
+	'V': {{"V", "7", "7", "7"}},
+	'W': {{"W", "7", "7", "7"}},
+	'X': {{"X", "5", "54", "54"}},
+	'Y': {{"Y", "1", "-", "-"}},
+	'Z': {
+		{"ZHDZH", "2", "4", "4"}, {"ZDZH", "2", "4", "4"}, {"ZSCH", "4", "4", "4"},
+		{"ZDZ", "2", "4", "4"}, {"ZHD", "2", "43", "43"}, {"ZSH", "4", "4", "4"},
+		{"ZD", "2", "43", "43"}, {"ZH", "4", "4", "4"}, {"ZS", "4", "4", "4"},
+		{"Z", "4", "4", "4"},
+	},
+}
+
+// dmBranch is one way of coding a name with Daitch-Mokotoff alternatives
+type dmBranch struct {
+	key  string
+	last string // code of the last group of letters, which is not repeated
+}
+
+// DaitchMokotoffKeys returns the Daitch-Mokotoff Soundex codes of a name,
+// like 463000 for Schmidt. A name has more than one code when some of its
+// letters can be pronounced more than one way.
+func DaitchMokotoffKeys(name string) []string {
+	s := phoneticLetters(name, false)
+	if s == "" {
+		return nil
+	}
+
+	branches := []dmBranch{{}}
+	for i := 0; i < len(s); {
+		rule := dmRules[s[i]][len(dmRules[s[i]])-1]
+		for _, r := range dmRules[s[i]] {
+			if strings.HasPrefix(s[i:], r.letters) {
+				rule = r
+				break
+			}
+		}
+
+		code := rule.other
+		next := i + len(rule.letters)
+		switch {
+		case i == 0:
+			code = rule.start
+		case next < len(s) && strings.IndexByte("AEIOU", s[next]) >= 0:
+			code = rule.vowel
+		}
+		i = next
+
+		var grown []dmBranch
+		for _, b := range branches {
+			for _, alt := range strings.Split(code, "|") {
+				if alt == "-" {
+					alt = ""
+				}
+				nb := b
+				if alt == "" || alt != b.last {
+					nb.key += alt
+				}
+				nb.last = alt
+				grown = append(grown, nb)
+			}
+		}
+		branches = grown
+	}
+
+	var keys []string
+	seen := make(map[string]bool)
+	for _, b := range branches {
+		key := (b.key + "000000")[:6]
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// metaphone holds the state of DoubleMetaphoneKeys
+type metaphone struct {
+	s                  string
+	primary, alternate strings.Builder
+	slavoGermanic      bool
+}
+
+// at reports whether any of subs is at position i
+func (m *metaphone) at(i int, subs ...string) bool {
+	if i < 0 {
+		return false
+	}
+	for _, sub := range subs {
+		if strings.HasPrefix(m.s[i:], sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// char returns the letter at position i, or 0
+func (m *metaphone) char(i int) byte {
+	if i < 0 || i >= len(m.s) {
+		return 0
+	}
+	return m.s[i]
+}
+
+// vowel reports whether the letter at position i is a vowel
+func (m *metaphone) vowel(i int) bool {
+	c := m.char(i)
+	return c != 0 && strings.IndexByte("AEIOUY", c) >= 0
+}
+
+// add adds to the primary and the alternate keys
+func (m *metaphone) add(s string) {
+	m.primary.WriteString(s)
+	m.alternate.WriteString(s)
+}
+
+// add2 adds different sounds to the primary and the alternate keys
+func (m *metaphone) add2(primary, alternate string) {
+	m.primary.WriteString(primary)
+	m.alternate.WriteString(alternate)
+}
+
+// skip returns the number of letters to move past a letter which may be
+// doubled
+func (m *metaphone) skip(i int) int {
+	if m.char(i+1) == m.char(i) {
+		return 2
+	}
+	return 1
+}
+
+// DoubleMetaphoneKeys returns the primary and alternate Double Metaphone
+// keys of a name, like XMT and SMT for Schmidt. The alternate key is the
+// same as the primary one unless the name may be pronounced another way.
+func DoubleMetaphoneKeys(name string) (primary string, alternate string) {
+	s := phoneticLetters(name, true)
+	if s == "" {
+		return "", ""
+	}
+	m := &metaphone{s: s + "     "}
+	m.slavoGermanic = strings.Contains(s, "W") || strings.Contains(s, "K") ||
+		strings.Contains(s, "CZ") || strings.Contains(s, "WITZ")
+	last := len(s) - 1
+
+	i := 0
+	if m.at(0, "GN", "KN", "PN", "WR", "PS") {
+		i++
+	}
+	if m.char(0) == 'X' {
+		m.add("S")
+		i++
+	}
+
+	for (m.primary.Len() < 4 || m.alternate.Len() < 4) && i <= last {
+		switch m.char(i) {
+		case 'A', 'E', 'I', 'O', 'U', 'Y':
+			if i == 0 {
+				m.add("A")
+			}
+			i++
+		case 'B':
+			m.add("P")
+			i += m.skip(i)
+		case 'C':
+			i += m.c(i)
+		case 'D':
+			switch {
+			case m.at(i, "DG") && m.at(i+2, "I", "E", "Y"):
+				m.add("J")
+				i += 3
+			case m.at(i, "DG"):
+				m.add("TK")
+				i += 2
+			case m.at(i, "DT", "DD"):
+				m.add("T")
+				i += 2
+			default:
+				m.add("T")
+				i++
+			}
+		case 'F':
+			m.add("F")
+			i += m.skip(i)
+		case 'G':
+			i += m.g(i)
+		case 'H':
+			if (i == 0 || m.vowel(i-1)) && m.vowel(i+1) {
+				m.add("H")
+				i += 2
+			} else {
+				i++
+			}
+		case 'J':
+			i += m.j(i, last)
+		case 'K':
+			m.add("K")
+			i += m.skip(i)
+		case 'L':
+			if m.char(i+1) == 'L' {
+				if (i == len(s)-3 && m.at(i-1, "ILLO", "ILLA", "ALLE")) ||
+					((m.at(last-1, "AS", "OS") || m.at(last, "A", "O")) && m.at(i-1, "ALLE")) {
+					m.add2("L", "")
+				} else {
+					m.add("L")
+				}
+				i += 2
+			} else {
+				m.add("L")
+				i++
+			}
+		case 'M':
+			if (m.at(i-1, "UMB") && (i+1 == last || m.at(i+2, "ER"))) || m.char(i+1) == 'M' {
+				i += 2
+			} else {
+				i++
+			}
+			m.add("M")
+		case 'N':
+			m.add("N")
+			i += m.skip(i)
+		case 'P':
+			switch {
+			case m.char(i+1) == 'H':
+				m.add("F")
+				i += 2
+			case m.at(i+1, "P", "B"):
+				m.add("P")
+				i += 2
+			default:
+				m.add("P")
+				i++
+			}
+		case 'Q':
+			m.add("K")
+			i += m.skip(i)
+		case 'R':
+			if i == last && !m.slavoGermanic && m.at(i-2, "IE") && !m.at(i-4, "ME", "MA") {
+				m.add2("", "R")
+			} else {
+				m.add("R")
+			}
+			i += m.skip(i)
+		case 'S':
+			i += m.sound(i, last)
+		case 'T':
+			switch {
+			case m.at(i, "TION", "TIA", "TCH"):
+				m.add("X")
+				i += 3
+			case m.at(i, "TH", "TTH"):
+				if m.at(i+2, "OM", "AM") || m.at(0, "VAN ", "VON ", "SCH") {
+					m.add("T")
+				} else {
+					m.add2("0", "T")
+				}
+				i += 2
+			case m.at(i+1, "T", "D"):
+				m.add("T")
+				i += 2
+			default:
+				m.add("T")
+				i++
+			}
+		case 'V':
+			m.add("F")
+			i += m.skip(i)
+		case 'W':
+			i += m.w(i, last)
+		case 'X':
+			if !(i == last && (m.at(i-3, "IAU", "EAU") || m.at(i-2, "AU", "OU"))) {
+				m.add("KS")
+			}
+			if m.at(i+1, "C", "X") {
+				i += 2
+			} else {
+				i++
+			}
+		case 'Z':
+			if m.char(i+1) == 'H' {
+				m.add("J")
+				i += 2
+				break
+			}
+			if m.at(i+1, "ZO", "ZI", "ZA") || (m.slavoGermanic && i > 0 && m.char(i-1) != 'T') {
+				m.add2("S", "TS")
+			} else {
+				m.add("S")
+			}
+			i += m.skip(i)
+		default:
+			i++
+		}
+	}
+
+	primary, alternate = m.primary.String(), m.alternate.String()
+	if len(primary) > 4 {
+		primary = primary[:4]
+	}
+	if len(alternate) > 4 {
+		alternate = alternate[:4]
+	}
+	return primary, alternate
+}
+
+// c codes a C at position i, returning the number of letters used
+func (m *metaphone) c(i int) int {
+	switch {
+	// Germanic, like Bacher and Macher
+	case i > 1 && !m.vowel(i-2) && m.at(i-1, "ACH") && m.char(i+2) != 'I' &&
+		(m.char(i+2) != 'E' || m.at(i-2, "BACHER", "MACHER")):
+		m.add("K")
+		return 2
+	case i == 0 && m.at(i, "CAESAR"):
+		m.add("S")
+		return 2
+	case m.at(i, "CHIA"):
+		m.add("K")
+		return 2
+	case m.at(i, "CH"):
+		switch {
+		case i > 0 && m.at(i, "CHAE"):
+			m.add2("K", "X")
+		// Greek roots, like chemistry and chorus
+		case i == 0 && (m.at(i+1, "HARAC", "HARIS") || m.at(i+1, "HOR", "HYM", "HIA", "HEM")) && !m.at(0, "CHORE"):
+			m.add("K")
+		case m.at(0, "VAN ", "VON ", "SCH") || m.at(i-2, "ORCHES", "ARCHIT", "ORCHID") || m.at(i+2, "T", "S") ||
+			((m.at(i-1, "A", "O", "U", "E") || i == 0) && m.at(i+2, "L", "R", "N", "M", "B", "H", "F", "V", "W", " ")):
+			m.add("K")
+		case i > 0 && m.at(0, "MC"):
+			m.add("K")
+		case i > 0:
+			m.add2("X", "K")
+		default:
+			m.add("X")
+		}
+		return 2
+	case m.at(i, "CZ") && !m.at(i-2, "WICZ"):
+		m.add2("S", "X")
+		return 2
+	case m.at(i+1, "CIA"):
+		m.add("X")
+		return 3
+	case m.at(i, "CC") && !(i == 1 && m.char(0) == 'M'):
+		if m.at(i+2, "I", "E", "H") && !m.at(i+2, "HU") {
+			// accident and succeed, but bacci and bertucci
+			if (i == 1 && m.char(0) == 'A') || m.at(i-1, "UCCEE", "UCCES") {
+				m.add("KS")
+			} else {
+				m.add("X")
+			}
+			return 3
+		}
+		m.add("K")
+		return 2
+	case m.at(i, "CK", "CG", "CQ"):
+		m.add("K")
+		return 2
+	case m.at(i, "CI", "CE", "CY"):
+		if m.at(i, "CIO", "CIE", "CIA") {
+			m.add2("S", "X")
+		} else {
+			m.add("S")
+		}
+		return 2
+	}
+
+	m.add("K")
+	switch {
+	// Mac Caffrey and Mac Gregor
+	case m.at(i+1, " C", " Q", " G"):
+		return 3
+	case m.at(i+1, "C", "K", "Q") && !m.at(i+1, "CE", "CI"):
+		return 2
+	}
+	return 1
+}
+
+// g codes a G at position i, returning the number of letters used
+func (m *metaphone) g(i int) int {
+	if m.char(i+1) == 'H' {
+		switch {
+		case i > 0 && !m.vowel(i-1):
+			m.add("K")
+		case i == 0 && m.char(i+2) == 'I':
+			m.add("J")
+		case i == 0:
+			m.add("K")
+		// silent, like Hugh and bought
+		case (i > 1 && m.at(i-2, "B", "H", "D")) || (i > 2 && m.at(i-3, "B", "H", "D")) || (i > 3 && m.at(i-4, "B", "H")):
+		// laugh, cough, rough and tough
+		case i > 2 && m.char(i-1) == 'U' && m.at(i-3, "C", "G", "L", "R", "T"):
+			m.add("F")
+		case i > 0 && m.char(i-1) != 'I':
+			m.add("K")
+		}
+		return 2
+	}
+
+	if m.char(i+1) == 'N' {
+		switch {
+		case i == 1 && m.vowel(0) && !m.slavoGermanic:
+			m.add2("KN", "N")
+		case !m.at(i+2, "EY") && m.char(i+1) != 'Y' && !m.slavoGermanic:
+			m.add2("N", "KN")
+		default:
+			m.add("KN")
+		}
+		return 2
+	}
+
+	switch {
+	case m.at(i+1, "LI") && !m.slavoGermanic:
+		m.add2("KL", "L")
+		return 2
+	case i == 0 && (m.char(i+1) == 'Y' || m.at(i+1, "ES", "EP", "EB", "EL", "EY", "IB", "IL", "IN", "IE", "EI", "ER")):
+		m.add2("K", "J")
+		return 2
+	case (m.at(i+1, "ER") || m.char(i+1) == 'Y') && !m.at(0, "DANGER", "RANGER", "MANGER") &&
+		!m.at(i-1, "E", "I") && !m.at(i-1, "RGY", "OGY"):
+		m.add2("K", "J")
+		return 2
+	case m.at(i+1, "E", "I", "Y") || m.at(i-1, "AGGI", "OGGI"):
+		switch {
+		case m.at(0, "VAN ", "VON ", "SCH") || m.at(i+1, "ET"):
+			m.add("K")
+		case m.at(i+1, "IER "):
+			m.add("J")
+		default:
+			m.add2("J", "K")
+		}
+		return 2
+	}
+
+	m.add("K")
+	return m.skip(i)
+}
+
+// j codes a J at position i, returning the number of letters used
+func (m *metaphone) j(i, last int) int {
+	if m.at(i, "JOSE") || m.at(0, "SAN ") {
+		if (i == 0 && m.char(i+4) == ' ') || m.at(0, "SAN ") {
+			m.add("H")
+		} else {
+			m.add2("J", "H")
+		}
+		return 1
+	}
+
+	switch {
+	case i == 0:
+		m.add2("J", "A")
+	case m.vowel(i-1) && !m.slavoGermanic && (m.char(i+1) == 'A' || m.char(i+1) == 'O'):
+		m.add2("J", "H")
+	case i == last:
+		m.add2("J", "")
+	case !m.at(i+1, "L", "T", "K", "S", "N", "M", "B", "Z") && !m.at(i-1, "S", "K", "L"):
+		m.add("J")
+	}
+	return m.skip(i)
+}
+
+// sound codes an S at position i, returning the number of letters used
+func (m *metaphone) sound(i, last int) int {
+	switch {
+	// island, isle and carlisle
+	case m.at(i-1, "ISL", "YSL"):
+		return 1
+	case i == 0 && m.at(i, "SUGAR"):
+		m.add2("X", "S")
+		return 1
+	case m.at(i, "SH"):
+		if m.at(i+1, "HEIM", "HOEK", "HOLM", "HOLZ") {
+			m.add("S")
+		} else {
+			m.add("X")
+		}
+		return 2
+	case m.at(i, "SIO", "SIA"):
+		if m.slavoGermanic {
+			m.add("S")
+		} else {
+			m.add2("S", "X")
+		}
+		return 3
+	// Smith for Schmidt and Snider for Schneider
+	case (i == 0 && m.at(i+1, "M", "N", "L", "W")) || m.at(i+1, "Z"):
+		m.add2("S", "X")
+		if m.at(i+1, "Z") {
+			return 2
+		}
+		return 1
+	case m.at(i, "SC"):
+		switch {
+		case m.char(i+2) == 'H' && m.at(i+3, "ER", "EN"):
+			m.add2("X", "SK")
+		case m.char(i+2) == 'H' && m.at(i+3, "OO", "UY", "ED", "EM"):
+			m.add("SK")
+		case m.char(i+2) == 'H' && i == 0 && !m.vowel(3) && m.char(3) != 'W':
+			m.add2("X", "S")
+		case m.char(i+2) == 'H':
+			m.add("X")
+		case m.at(i+2, "I", "E", "Y"):
+			m.add("S")
+		default:
+			m.add("SK")
+		}
+		return 3
+	}
+
+	// French, like Resnais and Artois
+	if i == last && m.at(i-2, "AI", "OI") {
+		m.add2("", "S")
+	} else {
+		m.add("S")
+	}
+	if m.at(i+1, "S", "Z") {
+		return 2
+	}
+	return 1
+}
+
+// w codes a W at position i, returning the number of letters used
+func (m *metaphone) w(i, last int) int {
+	if m.at(i, "WR") {
+		m.add("R")
+		return 2
+	}
+	if i == 0 && (m.vowel(i+1) || m.at(i, "WH")) {
+		// Wasserman and Vasserman
+		if m.vowel(i + 1) {
+			m.add2("A", "F")
+		} else {
+			m.add("A")
+		}
+	}
+	// Arnow and Arnoff
+	if (i == last && m.vowel(i-1)) || m.at(i-1, "EWSKI", "EWSKY", "OWSKI", "OWSKY") || m.at(0, "SCH") {
+		m.add2("", "F")
+		return 1
+	}
+	if m.at(i, "WICZ", "WITZ") {
+		m.add2("TS", "FX")
+		return 4
+	}
+	return 1
+}
+
+// PhoneticKeys returns the keys of a name by an algorithm. Names match when
+// they have a key in common.
+func PhoneticKeys(name string, algorithm PhoneticAlgorithm) []string {
+	switch algorithm {
+	case Soundex:
+		if key := SoundexKey(name); key != "" {
+			return []string{key}
+		}
+	case DaitchMokotoff:
+		return DaitchMokotoffKeys(name)
+	case DoubleMetaphone:
+		primary, alternate := DoubleMetaphoneKeys(name)
+		switch {
+		case primary == "":
+			return nil
+		case alternate == primary:
+			return []string{primary}
+		}
+		return []string{primary, alternate}
+	}
+	return nil
+}
+
+// phoneticMatch reports whether two names have a phonetic key in common
+func phoneticMatch(a, b string, algorithm PhoneticAlgorithm) bool {
+	keys := PhoneticKeys(b, algorithm)
+	for _, ka := range PhoneticKeys(a, algorithm) {
+		for _, kb := range keys {
+			if ka == kb {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NameSearch selects individuals by the sound of their names and the year
+// they were born
+type NameSearch struct {
+	Surname   string            // surname to match, or blank for any
+	Given     string            // given names to match, each with one of the given names of the individual, or blank for any
+	Algorithm PhoneticAlgorithm // how names are compared
+	BornFrom  int               // earliest year of birth, or 0 for no limit
+	BornTo    int               // latest year of birth, or 0 for no limit
+}
+
+// birthEvents are the events which date a birth, the best first
+var birthEvents = []string{"BIRT", "CHR", "BAPM"}
+
+// birthDate returns the parsed date of the birth, christening or baptism
+// of an individual, or nil
+func (r *IndividualRecord) birthDate() *ParsedDate {
+	for _, tag := range birthEvents {
+		for _, e := range r.Event {
+			if e.Tag != tag || e.Date == nil {
+				continue
+			}
+			if p, err := e.Date.Parse(); err == nil {
+				return p
+			}
+		}
+	}
+	return nil
+}
+
+// bornWithin reports whether the individual may have been born within
+// the years of the search. Without a window every individual is; with one
+// an individual needs a BIRT, CHR or BAPM date which overlaps it.
+func (q *NameSearch) bornWithin(r *IndividualRecord) bool {
+	if q.BornFrom == 0 && q.BornTo == 0 {
+		return true
+	}
+	p := r.birthDate()
+	if p == nil {
+		return false
+	}
+	earliest, latest, okEarliest, okLatest := p.Bounds()
+	if !okEarliest && !okLatest {
+		return false
+	}
+	if q.BornFrom != 0 && okLatest {
+		if year, _, _ := jdnToGregorian(latest); year < q.BornFrom {
+			return false
+		}
+	}
+	if q.BornTo != 0 && okEarliest {
+		if year, _, _ := jdnToGregorian(earliest); year > q.BornTo {
+			return false
+		}
+	}
+	return true
+}
+
+// matchName reports whether a name of an individual matches the search
+func (q *NameSearch) matchName(name *NameRecord) bool {
+	p := name.Parse()
+	if q.Surname != "" && !phoneticMatch(q.Surname, p.Surname, q.Algorithm) {
+		return false
+	}
+	givens := strings.Fields(p.Given)
+	for _, want := range strings.Fields(q.Given) {
+		found := false
+		for _, given := range givens {
+			if phoneticMatch(want, given, q.Algorithm) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Match reports whether the individual has a name which matches the search
+// and may have been born within its years
+func (q *NameSearch) Match(r *IndividualRecord) bool {
+	if !q.bornWithin(r) {
+		return false
+	}
+	for _, name := range r.Name {
+		if q.matchName(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Search returns the individuals which match a NameSearch
+func (r IndividualRecords) Search(q *NameSearch) IndividualRecords {
+	var found IndividualRecords
+	for _, indi := range r {
+		if q.Match(indi) {
+			found = append(found, indi)
+		}
+	}
+	return found
+}
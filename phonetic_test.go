@@ -0,0 +1,140 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestSoundexKey(t *testing.T) {
+	examples := []struct {
+		name string
+		want string
+	}{
+		{"Robert", "R163"},
+		{"Rupert", "R163"},
+		{"Ashcraft", "A261"},
+		{"Tymczak", "T522"},
+		{"Pfister", "P236"},
+		{"Honeyman", "H555"},
+		{"Lee", "L000"},
+		{"Schmidt", "S530"},
+		{"Smith", "S530"},
+		{"Müller", "M460"},
+		{"O'Brien", "O165"},
+		{"", ""},
+	}
+
+	for _, ex := range examples {
+		if got := SoundexKey(ex.name); got != ex.want {
+			t.Errorf("SoundexKey(%q) = %q, expected %q", ex.name, got, ex.want)
+		}
+	}
+}
+
+func TestDaitchMokotoffKeys(t *testing.T) {
+	examples := []struct {
+		name string
+		want []string
+	}{
+		{"Moskowitz", []string{"645740"}},
+		{"Schmidt", []string{"463000"}},
+		{"Smith", []string{"463000"}},
+		{"Schmitt", []string{"463000"}},
+		{"Peters", []string{"739400", "734000"}},
+		{"Auerbach", []string{"097500", "097400"}},
+		{"Jackson", []string{"154600", "145460", "454600", "445460"}},
+		{"", nil},
+	}
+
+	for _, ex := range examples {
+		if diff := deep.Equal(DaitchMokotoffKeys(ex.name), ex.want); diff != nil {
+			t.Errorf("DaitchMokotoffKeys(%q): %v", ex.name, diff)
+		}
+	}
+}
+
+func TestDoubleMetaphoneKeys(t *testing.T) {
+	examples := []struct {
+		name      string
+		primary   string
+		alternate string
+	}{
+		{"Schmidt", "XMT", "SMT"},
+		{"Smith", "SM0", "XMT"},
+		{"Schmitt", "XMT", "SMT"},
+		{"Thomas", "TMS", "TMS"},
+		{"Jose", "HS", "HS"},
+		{"Wasserman", "ASRM", "FSRM"},
+		{"Knight", "NT", "NT"},
+		{"Caesar", "SSR", "SSR"},
+		{"Filipowicz", "FLPT", "FLPF"},
+		{"Xavier", "SF", "SFR"},
+		{"", "", ""},
+	}
+
+	for _, ex := range examples {
+		primary, alternate := DoubleMetaphoneKeys(ex.name)
+		if primary != ex.primary || alternate != ex.alternate {
+			t.Errorf("DoubleMetaphoneKeys(%q) = %q, %q, expected %q, %q", ex.name, primary, alternate, ex.primary, ex.alternate)
+		}
+	}
+}
+
+var searchInput = `0 HEAD
+0 @I1@ INDI
+1 NAME Johann /Schmidt/
+1 BIRT
+2 DATE 12 MAR 1842
+0 @I2@ INDI
+1 NAME John William /Smith/
+1 BIRT
+2 DATE ABT 1860
+0 @I3@ INDI
+1 NAME Anna /Schmitt/
+1 CHR
+2 DATE 1851
+0 @I4@ INDI
+1 NAME Jon /Smyth/
+0 @I5@ INDI
+1 NAME John /Jones/
+1 BIRT
+2 DATE BEF 1800
+0 TRLR
+`
+
+func TestSearch(t *testing.T) {
+	d := NewDecoder(bytes.NewReader([]byte(searchInput)))
+	g, err := d.Decode()
+	if err != nil {
+		t.Fatalf("Decode returned error %v", err)
+	}
+
+	examples := []struct {
+		name string
+		q    NameSearch
+		want []string
+	}{
+		{"Soundex surname", NameSearch{Surname: "Smith", Algorithm: Soundex}, []string{"@I1@", "@I2@", "@I3@", "@I4@"}},
+		{"Daitch-Mokotoff surname", NameSearch{Surname: "Schmidt", Algorithm: DaitchMokotoff}, []string{"@I1@", "@I2@", "@I3@", "@I4@"}},
+		{"Double Metaphone surname", NameSearch{Surname: "Schmidt", Algorithm: DoubleMetaphone}, []string{"@I1@", "@I2@", "@I3@", "@I4@"}},
+		{"given", NameSearch{Surname: "Smith", Given: "Jon", Algorithm: Soundex}, []string{"@I1@", "@I2@", "@I4@"}},
+		{"second given", NameSearch{Given: "William", Algorithm: Soundex}, []string{"@I2@"}},
+		{"born from", NameSearch{Surname: "Smith", BornFrom: 1850}, []string{"@I2@", "@I3@"}},
+		{"born to", NameSearch{Surname: "Smith", BornTo: 1850}, []string{"@I1@", "@I2@"}},
+		{"born between", NameSearch{Surname: "Smith", BornFrom: 1840, BornTo: 1845}, []string{"@I1@"}},
+		{"born before", NameSearch{Given: "John", BornTo: 1820}, []string{"@I5@"}},
+	}
+
+	for _, ex := range examples {
+		if diff := deep.Equal(xrefs(g.Individual.Search(&ex.q)), ex.want); diff != nil {
+			t.Errorf("%s: %v", ex.name, diff)
+		}
+	}
+}
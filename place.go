@@ -0,0 +1,129 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"sort"
+	"strings"
+)
+
+// Jurisdiction represents one part of a place name
+type Jurisdiction struct {
+	Name  string // jurisdiction from PLAC.FORM, like County, or blank
+	Value string // part of the place name, like Cork, or blank
+}
+
+// splitPlace splits a place name or form at its commas
+func splitPlace(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	parts := strings.Split(s, ",")
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(part)
+	}
+	return parts
+}
+
+// Jurisdictions returns the parts of the place name from the smallest
+// jurisdiction to the largest, named from the FORM of the place or, when
+// it has none, from the PLAC.FORM of head, which may be nil. Names are
+// blank beyond the end of the form.
+func (r *PlaceRecord) Jurisdictions(head *HeaderRecord) []Jurisdiction {
+	form := r.Form
+	if form == "" && head != nil && head.Place != nil {
+		form = head.Place.Form
+	}
+	names := splitPlace(form)
+
+	var js []Jurisdiction
+	for i, value := range splitPlace(r.Name) {
+		j := Jurisdiction{Value: value}
+		if i < len(names) {
+			j.Name = names[i]
+		}
+		js = append(js, j)
+	}
+	return js
+}
+
+// Places returns every PLAC of the events and attributes of individuals
+// and families, of level 0 EVEN and PLAC records and of the DATA of
+// sources.
+func (r *RootRecord) Places() PlaceRecords {
+	var places PlaceRecords
+
+	add := func(p *PlaceRecord) {
+		if p != nil {
+			places = append(places, p)
+		}
+	}
+	addEvents := func(events EventRecords) {
+		for _, e := range events {
+			add(e.Place)
+			add(e.Place2_)
+		}
+	}
+
+	for _, indi := range r.Individual {
+		addEvents(indi.Event)
+		for _, a := range indi.Attribute {
+			add(a.Place)
+			add(a.Place2_)
+		}
+	}
+	for _, fam := range r.Family {
+		addEvents(fam.Event)
+	}
+	addEvents(r.Event)
+	for _, p := range r.Place {
+		add(p)
+	}
+	for _, sour := range r.Source {
+		if sour.Data != nil {
+			addEvents(sour.Data.Event)
+		}
+	}
+
+	return places
+}
+
+// GazetteerEntry represents a distinct place name and where it is used
+type GazetteerEntry struct {
+	Name          string         // place name with its parts tidied
+	Jurisdictions []Jurisdiction // parts of the place name
+	Places        PlaceRecords   // every PLAC with the name
+}
+
+// Gazetteer represents the distinct place names of a RootRecord
+type Gazetteer []*GazetteerEntry
+
+// Gazetteer returns the distinct place names of Places, ignoring case and
+// the spaces around commas, sorted by name
+func (r *RootRecord) Gazetteer() Gazetteer {
+	var g Gazetteer
+	entries := make(map[string]*GazetteerEntry)
+
+	for _, p := range r.Places() {
+		name := strings.Join(splitPlace(p.Name), ", ")
+		if name == "" {
+			continue
+		}
+		key := strings.ToLower(name)
+		entry, found := entries[key]
+		if !found {
+			entry = &GazetteerEntry{Name: name, Jurisdictions: p.Jurisdictions(r.Header)}
+			entries[key] = entry
+			g = append(g, entry)
+		}
+		entry.Places = append(entry.Places, p)
+	}
+
+	sort.SliceStable(g, func(i, j int) bool {
+		return strings.ToLower(g[i].Name) < strings.ToLower(g[j].Name)
+	})
+	return g
+}
@@ -0,0 +1,126 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+var placeInput = `0 HEAD
+1 PLAC
+2 FORM City, County, Country
+0 @I1@ INDI
+1 BIRT
+2 PLAC Cork, Cork, Ireland
+1 DEAT
+2 PLAC cork ,Cork,  Ireland
+1 BURI
+2 PLAC Boston, Suffolk, Massachusetts, USA
+3 FORM City, County, State, Country
+0 @F1@ FAM
+1 MARR
+2 PLAC Dublin,,Ireland
+0 TRLR
+`
+
+func TestJurisdictions(t *testing.T) {
+	d := NewDecoder(bytes.NewReader([]byte(placeInput)))
+	g, err := d.Decode()
+	if err != nil {
+		t.Fatalf("Decode returned error %v", err)
+	}
+
+	indi := g.Individual[0]
+	want := []Jurisdiction{{"City", "Cork"}, {"County", "Cork"}, {"Country", "Ireland"}}
+	if diff := deep.Equal(indi.Event[0].Place.Jurisdictions(g.Header), want); diff != nil {
+		t.Errorf("Jurisdictions with HEAD.PLAC.FORM: %v", diff)
+	}
+
+	want = []Jurisdiction{{"City", "Boston"}, {"County", "Suffolk"}, {"State", "Massachusetts"}, {"Country", "USA"}}
+	if diff := deep.Equal(indi.Event[2].Place.Jurisdictions(g.Header), want); diff != nil {
+		t.Errorf("Jurisdictions with PLAC.FORM: %v", diff)
+	}
+
+	want = []Jurisdiction{{"City", "Dublin"}, {"County", ""}, {"Country", "Ireland"}}
+	if diff := deep.Equal(g.Family[0].Event[0].Place.Jurisdictions(g.Header), want); diff != nil {
+		t.Errorf("Jurisdictions with a blank part: %v", diff)
+	}
+
+	g.Header.Place.Form = "Town, Shire, Land"
+	want = []Jurisdiction{{"Town", "Cork"}, {"Shire", "Cork"}, {"Land", "Ireland"}}
+	if diff := deep.Equal(indi.Event[0].Place.Jurisdictions(g.Header), want); diff != nil {
+		t.Errorf("Jurisdictions with an edited HEAD.PLAC.FORM: %v", diff)
+	}
+
+	p := &PlaceRecord{Name: "Paris, France"}
+	want = []Jurisdiction{{"", "Paris"}, {"", "France"}}
+	if diff := deep.Equal(p.Jurisdictions(nil), want); diff != nil {
+		t.Errorf("Jurisdictions without a form: %v", diff)
+	}
+
+	// records read one at a time
+	d = NewDecoder(bytes.NewReader([]byte(placeInput)))
+	var h *HeaderRecord
+	for {
+		rec, err := d.Next()
+		if err != nil {
+			break
+		}
+		switch rec := rec.(type) {
+		case *HeaderRecord:
+			h = rec
+		case *IndividualRecord:
+			indi = rec
+		}
+	}
+	want = []Jurisdiction{{"City", "Cork"}, {"County", "Cork"}, {"Country", "Ireland"}}
+	if diff := deep.Equal(indi.Event[0].Place.Jurisdictions(h), want); diff != nil {
+		t.Errorf("Jurisdictions with Next: %v", diff)
+	}
+
+	head := &HeaderRecord{Place: &PlaceRecord{Form: "City, Country"}}
+	want = []Jurisdiction{{"City", "Paris"}, {"Country", "France"}}
+	if diff := deep.Equal(p.Jurisdictions(head), want); diff != nil {
+		t.Errorf("Jurisdictions with a built HEAD.PLAC.FORM: %v", diff)
+	}
+}
+
+func TestGazetteer(t *testing.T) {
+	d := NewDecoder(bytes.NewReader([]byte(placeInput)))
+	g, err := d.Decode()
+	if err != nil {
+		t.Fatalf("Decode returned error %v", err)
+	}
+
+	gaz := g.Gazetteer()
+	var names []string
+	for _, entry := range gaz {
+		names = append(names, entry.Name)
+	}
+	want := []string{"Boston, Suffolk, Massachusetts, USA", "Cork, Cork, Ireland", "Dublin, , Ireland"}
+	if diff := deep.Equal(names, want); diff != nil {
+		t.Errorf("Gazetteer names: %v", diff)
+	}
+	if len(gaz) == 3 && len(gaz[1].Places) != 2 {
+		t.Errorf("Gazetteer entry %q has %d places, expected 2", gaz[1].Name, len(gaz[1].Places))
+	}
+}
+
+func TestPlaceFormWrite(t *testing.T) {
+	p := &PlaceRecord{Level: 2, Tag: "PLAC", Name: "Cork, Ireland", Form: "City, Country", ShortName: "Cork"}
+
+	var buf bytes.Buffer
+	if _, err := p.Write(&buf); err != nil {
+		t.Fatalf("Write returned error %v", err)
+	}
+	want := "    2 PLAC Cork, Ireland\n      3 FORM City, Country\n      3 PLAS Cork\n"
+	if buf.String() != want {
+		t.Errorf("Write = %q, expected %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,408 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Query selects level 0 records with a condition on the lines under them,
+// addressed by their GEDCOM tag paths, like
+//
+//	INDI where BIRT.DATE < 1850 and BIRT.PLAC contains "Cork" and not FAMS
+//
+// A query starts with the tag of the records to select, or * for any, and
+// optionally where and a condition. Paths may start with the tag of the
+// record, as in the comments in types.go, or leave it out. A path on its
+// own is true if the record has a line there. A path compared with a value
+// by =, !=, <, <=, > or >=, or by contains, is true if any line there has a
+// value which compares so.
+// Values are compared as dates when the path ends with DATE, as numbers
+// when both are numbers and otherwise as text, ignoring case. Dates
+// compare by the days they could be: = is true if every day falls within
+// the value, so 3 MAR 1842 = 1842 but not ABT 1850 = 1840, and != is its
+// opposite; < and > are true if the date is certainly before or after the
+// value, and <= and >= if it is not certainly after or before. Values with
+// spaces must be quoted. Conditions are combined with not, and, or and
+// parentheses.
+type Query struct {
+	Tag  string    // tag of the records to select, or *
+	cond queryExpr // condition, or nil for all records
+}
+
+// queryExpr is a condition of a Query
+type queryExpr interface {
+	eval(n *Node) bool
+}
+
+// queryAnd is true if both of its conditions are
+type queryAnd struct{ left, right queryExpr }
+
+func (e *queryAnd) eval(n *Node) bool { return e.left.eval(n) && e.right.eval(n) }
+
+// queryOr is true if either of its conditions is
+type queryOr struct{ left, right queryExpr }
+
+func (e *queryOr) eval(n *Node) bool { return e.left.eval(n) || e.right.eval(n) }
+
+// queryNot is true if its condition is not
+type queryNot struct{ expr queryExpr }
+
+func (e *queryNot) eval(n *Node) bool { return !e.expr.eval(n) }
+
+// queryCond tests the lines at a path, for existence if op is blank
+type queryCond struct {
+	path  []string
+	op    string
+	value string
+}
+
+func (e *queryCond) eval(n *Node) bool {
+	path := e.path
+	if strings.EqualFold(path[0], n.Tag) {
+		path = path[1:]
+	}
+	nodes := n.FindAll(strings.Join(path, "."))
+	if e.op == "" {
+		return len(nodes) > 0
+	}
+	date := strings.HasSuffix(strings.ToUpper(e.path[len(e.path)-1]), "DATE")
+	for _, node := range nodes {
+		if compareQueryValue(node.Text(), e.op, e.value, date) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareQueryValue compares the value of a line with the value of a
+// condition
+func compareQueryValue(value, op, want string, date bool) bool {
+	if op == "contains" {
+		return strings.Contains(strings.ToUpper(value), strings.ToUpper(want))
+	}
+
+	if date {
+		p, err1 := ParseDate(value)
+		q, err2 := ParseDate(want)
+		if err1 != nil || err2 != nil {
+			return false
+		}
+		if _, ok := p.SortKey(); !ok {
+			return false
+		}
+		if _, ok := q.SortKey(); !ok {
+			return false
+		}
+		before, after := p.Before(q), q.Before(p)
+		switch op {
+		case "=":
+			return dateWithin(p, q)
+		case "!=":
+			return !dateWithin(p, q)
+		case "<":
+			return before
+		case "<=":
+			return !after
+		case ">":
+			return after
+		case ">=":
+			return !before
+		}
+		return false
+	}
+
+	c := 0
+	x, err1 := strconv.ParseFloat(value, 64)
+	y, err2 := strconv.ParseFloat(want, 64)
+	switch {
+	case err1 == nil && err2 == nil && x < y:
+		c = -1
+	case err1 == nil && err2 == nil && x > y:
+		c = 1
+	case err1 != nil || err2 != nil:
+		c = strings.Compare(strings.ToUpper(value), strings.ToUpper(want))
+	}
+	switch op {
+	case "=":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+// dateWithin reports whether every day p could be falls within the days q
+// could be
+func dateWithin(p, q *ParsedDate) bool {
+	pe, pl, okpe, okpl := p.Bounds()
+	qe, ql, okqe, okql := q.Bounds()
+	return (!okqe || okpe && pe >= qe) && (!okql || okpl && pl <= ql)
+}
+
+// queryToken is a word, a quoted string or an operator of a query
+type queryToken struct {
+	text   string
+	quoted bool
+	pos    int // byte offset in the query
+}
+
+// queryLexer splits a query into tokens
+func queryLexer(s string) ([]queryToken, error) {
+	var tokens []queryToken
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == '"':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(s) && s[j] != '"'; j++ {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+				}
+				b.WriteByte(s[j])
+			}
+			if j == len(s) {
+				return nil, &QueryError{Query: s, Pos: i, Msg: "unterminated string"}
+			}
+			tokens = append(tokens, queryToken{text: b.String(), quoted: true, pos: i})
+			i = j + 1
+		case c == '(' || c == ')' || c == '=':
+			tokens = append(tokens, queryToken{text: s[i : i+1], pos: i})
+			i++
+		case c == '<' || c == '>' || c == '!':
+			j := i + 1
+			if j < len(s) && s[j] == '=' {
+				j++
+			}
+			if s[i:j] == "!" {
+				return nil, &QueryError{Query: s, Pos: i, Msg: "expected !="}
+			}
+			tokens = append(tokens, queryToken{text: s[i:j], pos: i})
+			i = j
+		default:
+			j := i
+			for j < len(s) && !strings.ContainsRune(" \t\r\n\"()=<>!", rune(s[j])) {
+				j++
+			}
+			tokens = append(tokens, queryToken{text: s[i:j], pos: i})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+// queryParser parses the tokens of a query
+type queryParser struct {
+	query  string
+	tokens []queryToken
+	pos    int
+}
+
+// peek returns the next token, or an empty one at the end
+func (p *queryParser) peek() queryToken {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return queryToken{pos: len(p.query)}
+}
+
+// keyword reports whether the next token is an unquoted keyword, and
+// consumes it if it is
+func (p *queryParser) keyword(word string) bool {
+	t := p.peek()
+	if !t.quoted && strings.EqualFold(t.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// fail returns an error at the next token
+func (p *queryParser) fail(msg string) error {
+	return &QueryError{Query: p.query, Pos: p.peek().pos, Msg: msg}
+}
+
+// or parses conditions separated by or
+func (p *queryParser) or() (queryExpr, error) {
+	left, err := p.and()
+	for err == nil && p.keyword("or") {
+		var right queryExpr
+		right, err = p.and()
+		left = &queryOr{left, right}
+	}
+	return left, err
+}
+
+// and parses conditions separated by and
+func (p *queryParser) and() (queryExpr, error) {
+	left, err := p.unary()
+	for err == nil && p.keyword("and") {
+		var right queryExpr
+		right, err = p.unary()
+		left = &queryAnd{left, right}
+	}
+	return left, err
+}
+
+// queryOps are the comparison operators
+var queryOps = map[string]bool{"=": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true}
+
+// unary parses a negated or parenthesised condition, or a path with an
+// optional comparison
+func (p *queryParser) unary() (queryExpr, error) {
+	if p.keyword("not") {
+		e, err := p.unary()
+		return &queryNot{e}, err
+	}
+
+	t := p.peek()
+	switch {
+	case t.text == "" && !t.quoted:
+		return nil, p.fail("expected a condition")
+	case t.text == "(" && !t.quoted:
+		p.pos++
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().text != ")" {
+			return nil, p.fail("expected )")
+		}
+		p.pos++
+		return e, nil
+	case t.quoted || t.text == ")" || queryOps[t.text]:
+		return nil, p.fail("expected a path")
+	}
+	p.pos++
+
+	cond := &queryCond{path: strings.Split(t.text, ".")}
+	for _, tag := range cond.path {
+		if tag == "" {
+			return nil, &QueryError{Query: p.query, Pos: t.pos, Msg: "empty tag in path"}
+		}
+	}
+
+	op := p.peek()
+	switch {
+	case op.quoted:
+		return nil, p.fail("expected an operator")
+	case queryOps[op.text]:
+		cond.op = op.text
+	case strings.EqualFold(op.text, "contains"):
+		cond.op = "contains"
+	default:
+		return cond, nil
+	}
+	p.pos++
+
+	v := p.peek()
+	if v.text == "" && !v.quoted || !v.quoted && (v.text == "(" || v.text == ")" || queryOps[v.text]) {
+		return nil, p.fail("expected a value")
+	}
+	p.pos++
+	cond.value = v.text
+	return cond, nil
+}
+
+// ParseQuery parses a query; see Query
+func ParseQuery(s string) (*Query, error) {
+	tokens, err := queryLexer(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{query: s, tokens: tokens}
+
+	t := p.peek()
+	if t.text == "" || t.quoted {
+		return nil, p.fail("expected a record tag")
+	}
+	p.pos++
+	q := &Query{Tag: strings.ToUpper(t.text)}
+
+	if p.keyword("where") {
+		if q.cond, err = p.or(); err != nil {
+			return nil, err
+		}
+	}
+	if p.pos < len(p.tokens) {
+		return nil, p.fail("unexpected " + strconv.Quote(p.peek().text))
+	}
+	return q, nil
+}
+
+// Match reports whether the level 0 node of a record is selected by the
+// query
+func (q *Query) Match(n *Node) bool {
+	if q.Tag != "*" && !strings.EqualFold(n.Tag, q.Tag) {
+		return false
+	}
+	return q.cond == nil || q.cond.eval(n)
+}
+
+// Find returns the level 0 nodes of a tree, other than HEAD and TRLR,
+// selected by the query
+func (q *Query) Find(tree *Node) []*Node {
+	var found []*Node
+	for _, n := range tree.Children {
+		if n.Tag != "HEAD" && n.Tag != "TRLR" && q.Match(n) {
+			found = append(found, n)
+		}
+	}
+	return found
+}
+
+// Query returns the level 0 nodes of a tree, such as one from ReadTree or
+// Tree, selected by a query; see Query. The tree can be queried any
+// number of times.
+func (n *Node) Query(query string) ([]*Node, error) {
+	q, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return q.Find(n), nil
+}
+
+// Query returns the level 0 records selected by a query; see Query. The
+// query is evaluated against the lines of r as Tree returns them, and the
+// records are found by the xrefs of the lines, so records without an xref
+// are not returned. To run several queries, call Tree once and use
+// Node.Query.
+func (r *RootRecord) Query(query string) ([]Record, error) {
+	q, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := r.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	byXref := make(map[string]Record)
+	for _, rec := range r.records() {
+		if xref := keysOf(rec).xref; xref != "" {
+			byXref[xref] = rec
+		}
+	}
+	var found []Record
+	for _, n := range q.Find(tree) {
+		if rec, ok := byXref[n.Xref]; ok {
+			found = append(found, rec)
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,130 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+var queryInput = `0 HEAD
+0 @I1@ INDI
+1 NAME Patrick /Murphy/
+1 SEX M
+1 BIRT
+2 DATE 3 MAR 1842
+2 PLAC Cork, Ireland
+0 @I2@ INDI
+1 NAME Mary /Murphy/
+1 SEX F
+1 BIRT
+2 DATE ABT 1860
+2 PLAC Cork, Ireland
+1 FAMS @F1@
+0 @I3@ INDI
+1 NAME Sean /Kelly/
+1 BIRT
+2 DATE BEF 1840
+2 PLAC Dublin, Ireland
+0 @F1@ FAM
+1 WIFE @I2@
+1 NCHI 3
+0 @N1@ NOTE Emigrated
+1 CONT to Boston
+0 TRLR
+`
+
+func TestQuery(t *testing.T) {
+	d := NewDecoder(bytes.NewReader([]byte(queryInput)))
+	g, err := d.Decode()
+	if err != nil {
+		t.Fatalf("Decode returned error %v", err)
+	}
+	tree, err := ReadTree(bytes.NewReader([]byte(queryInput)))
+	if err != nil {
+		t.Fatalf("ReadTree returned error %v", err)
+	}
+
+	examples := []struct {
+		query string
+		want  []string
+	}{
+		{`INDI`, []string{"@I1@", "@I2@", "@I3@"}},
+		{`INDI where BIRT.DATE < 1850 and BIRT.PLAC contains "Cork" and not FAMS`, []string{"@I1@"}},
+		{`indi WHERE birt.date < 1850`, []string{"@I1@", "@I3@"}},
+		{`INDI where BIRT.DATE >= 1850`, []string{"@I2@"}},
+		{`INDI where BIRT.DATE = 1842`, []string{"@I1@"}},
+		{`INDI where BIRT.DATE != 1842`, []string{"@I2@", "@I3@"}},
+		{`INDI where BIRT.DATE = 1860`, nil},
+		{`INDI where BIRT.DATE = "ABT 1860"`, []string{"@I2@"}},
+		{`INDI where BIRT.DATE = "BEF 1845"`, []string{"@I1@", "@I3@"}},
+		{`INDI where BIRT.DATE = "BET 1800 AND 1845"`, []string{"@I1@"}},
+		{`INDI where SEX = M or SEX = f`, []string{"@I1@", "@I2@"}},
+		{`INDI where not (SEX = M or SEX = F)`, []string{"@I3@"}},
+		{`INDI where NAME = "Mary /Murphy/"`, []string{"@I2@"}},
+		{`INDI where FAMS`, []string{"@I2@"}},
+		{`FAM where NCHI > 2 and NCHI < 10`, []string{"@F1@"}},
+		{`NOTE where NOTE contains "Boston"`, []string{"@N1@"}},
+		{`INDI where INDI.BIRT.PLAC contains Dublin`, []string{"@I3@"}},
+		{`* where BIRT.PLAC contains Dublin`, []string{"@I3@"}},
+		{`SOUR`, nil},
+	}
+
+	for _, ex := range examples {
+		found, err := g.Query(ex.query)
+		if err != nil {
+			t.Errorf("Query(%q) returned error %v", ex.query, err)
+			continue
+		}
+		if diff := deep.Equal(recordXrefs(found), ex.want); diff != nil {
+			t.Errorf("Query(%q): %v", ex.query, diff)
+		}
+
+		// the tree read from the input selects the same lines
+		nodes, err := tree.Query(ex.query)
+		if err != nil {
+			t.Errorf("Node.Query(%q) returned error %v", ex.query, err)
+			continue
+		}
+		var xrefs []string
+		for _, n := range nodes {
+			xrefs = append(xrefs, n.Xref)
+		}
+		if diff := deep.Equal(xrefs, ex.want); diff != nil {
+			t.Errorf("Node.Query(%q): %v", ex.query, diff)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	examples := []struct {
+		query string
+		pos   int
+	}{
+		{``, 0},
+		{`INDI where`, 10},
+		{`INDI where BIRT.DATE <`, 22},
+		{`INDI where (FAMS`, 16},
+		{`INDI where NAME = "Mary`, 18},
+		{`INDI where BIRT..DATE`, 11},
+		{`INDI where FAMS FAMC`, 16},
+		{`INDI where NAME ! x`, 16},
+	}
+
+	for _, ex := range examples {
+		_, err := ParseQuery(ex.query)
+		qe, ok := err.(*QueryError)
+		if !ok {
+			t.Errorf("ParseQuery(%q) returned %v, expected a QueryError", ex.query, err)
+			continue
+		}
+		if qe.Pos != ex.pos {
+			t.Errorf("ParseQuery(%q) error at %d, expected %d: %v", ex.query, qe.Pos, ex.pos, qe)
+		}
+	}
+}
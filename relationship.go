@@ -0,0 +1,328 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RelationshipPath represents a blood line between two individuals through
+// a common ancestor
+type RelationshipPath struct {
+	Ancestor *IndividualRecord // the common ancestor
+	Up       IndividualRecords // from the first individual to the ancestor
+	Down     IndividualRecords // from the ancestor to the second individual
+}
+
+// Generations returns the generations from each individual up to the ancestor
+func (p *RelationshipPath) Generations() (first int, second int) {
+	return len(p.Up) - 1, len(p.Down) - 1
+}
+
+// Relationship represents how one individual is related to another
+type Relationship struct {
+	CommonAncestors IndividualRecords   // nearest common ancestors, nearest first
+	Paths           []*RelationshipPath // blood lines, shortest first
+	Coefficient     float64             // coefficient of relationship
+	Label           string              // what the second individual is to the first, like "second cousin once removed"
+}
+
+// Relate finds how b is related to a. Blood lines follow FAMC links with
+// a birth pedigree; each goes up from a to a common ancestor and down to b
+// without any other individual in common, so that with pedigree collapse
+// there can be several. The coefficient of relationship adds 1/2 to the
+// power of the length of each line. The label describes the shortest line,
+// which is half when the two children of the ancestor on it do not have
+// the same parents by birth, or otherwise a spouse, step or in-law
+// relationship, and is blank if none is found.
+func Relate(a, b *IndividualRecord) *Relationship {
+	rel := &Relationship{Paths: bloodPaths(a, b)}
+
+	seen := make(map[*IndividualRecord]bool)
+	for _, p := range rel.Paths {
+		n1, n2 := p.Generations()
+		rel.Coefficient += math.Ldexp(1, -(n1 + n2))
+		if !seen[p.Ancestor] {
+			seen[p.Ancestor] = true
+			rel.CommonAncestors = append(rel.CommonAncestors, p.Ancestor)
+		}
+	}
+
+	if len(rel.Paths) > 0 {
+		p := rel.Paths[0]
+		n1, n2 := p.Generations()
+		half := false
+		if n1 > 0 && n2 > 0 {
+			// the lines part at two children of the ancestor, who are
+			// half siblings unless they have the same parents
+			birth := []string{PedigreeBirth}
+			x, y := p.Up[n1-1], p.Down[1]
+			half = !sameIndividuals(x.allParents(birth), y.allParents(birth))
+		}
+		rel.Label = bloodLabel(n1, n2, half, b.Sex)
+	} else {
+		rel.Label = affinityLabel(a, b)
+	}
+
+	return rel
+}
+
+// ancestorPaths returns every path from an individual up to each of its
+// ancestors by birth, including itself. Unlike Ancestors it follows each
+// line through a collapsed pedigree, but not around a loop.
+func ancestorPaths(indi *IndividualRecord) map[*IndividualRecord][]IndividualRecords {
+	paths := make(map[*IndividualRecord][]IndividualRecords)
+	var climb func(path IndividualRecords)
+	climb = func(path IndividualRecords) {
+		last := path[len(path)-1]
+		paths[last] = append(paths[last], path)
+		for _, parent := range last.allParents([]string{PedigreeBirth}) {
+			if !onPath(path, parent) {
+				climb(append(path[:len(path):len(path)], parent))
+			}
+		}
+	}
+	climb(IndividualRecords{indi})
+	return paths
+}
+
+// onPath reports whether an individual is on a path
+func onPath(path IndividualRecords, indi *IndividualRecord) bool {
+	for _, p := range path {
+		if p == indi {
+			return true
+		}
+	}
+	return false
+}
+
+// bloodPaths returns the blood lines between two individuals, shortest first
+func bloodPaths(a, b *IndividualRecord) []*RelationshipPath {
+	if a == nil || b == nil {
+		return nil
+	}
+	pathsA := ancestorPaths(a)
+	pathsB := ancestorPaths(b)
+
+	var paths []*RelationshipPath
+	for _, upB := range orderedPaths(pathsB) {
+		ancestor := upB[len(upB)-1]
+		for _, up := range pathsA[ancestor] {
+			if !disjoint(up, upB) {
+				continue
+			}
+			down := make(IndividualRecords, len(upB))
+			for i, indi := range upB {
+				down[len(upB)-1-i] = indi
+			}
+			paths = append(paths, &RelationshipPath{Ancestor: ancestor, Up: up, Down: down})
+		}
+	}
+
+	sort.SliceStable(paths, func(i, j int) bool {
+		i1, i2 := paths[i].Generations()
+		j1, j2 := paths[j].Generations()
+		if i1+i2 != j1+j2 {
+			return i1+i2 < j1+j2
+		}
+		return i1 < j1
+	})
+	return paths
+}
+
+// orderedPaths returns the paths of ancestorPaths, shortest first, so that
+// the results do not depend on the order of a map
+func orderedPaths(paths map[*IndividualRecord][]IndividualRecords) []IndividualRecords {
+	var list []IndividualRecords
+	for _, ps := range paths {
+		list = append(list, ps...)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if len(list[i]) != len(list[j]) {
+			return len(list[i]) < len(list[j])
+		}
+		for k := range list[i] {
+			if list[i][k] != list[j][k] {
+				return list[i][k].Xref < list[j][k].Xref
+			}
+		}
+		return false
+	})
+	return list
+}
+
+// disjoint reports whether two paths to the same ancestor have only the
+// ancestor in common
+func disjoint(p, q IndividualRecords) bool {
+	in := make(map[*IndividualRecord]bool)
+	for _, indi := range p[:len(p)-1] {
+		in[indi] = true
+	}
+	for _, indi := range q[:len(q)-1] {
+		if in[indi] {
+			return false
+		}
+	}
+	return true
+}
+
+// gendered returns the male, female or neutral word by sex
+func gendered(sex string, male, female, neutral string) string {
+	switch strings.ToUpper(sex) {
+	case "M":
+		return male
+	case "F":
+		return female
+	}
+	return neutral
+}
+
+// greats returns the prefix for an ancestor or a descendant n generations
+// beyond a grandparent or a grandchild: great-, great-great-, 3rd great-, ...
+func greats(n int) string {
+	switch {
+	case n <= 0:
+		return ""
+	case n <= 2:
+		return strings.Repeat("great-", n)
+	}
+	return ordinal(n) + " great-"
+}
+
+// ordinal returns a number like 1st, 2nd, 3rd or 4th
+func ordinal(n int) string {
+	suffix := "th"
+	switch n % 10 {
+	case 1:
+		suffix = "st"
+	case 2:
+		suffix = "nd"
+	case 3:
+		suffix = "rd"
+	}
+	if n%100 >= 11 && n%100 <= 13 {
+		suffix = "th"
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+var cousinOrdinals = []string{"", "first", "second", "third", "fourth", "fifth", "sixth", "seventh", "eighth", "ninth", "tenth"}
+
+var removedTimes = []string{"", " once removed", " twice removed", " three times removed"}
+
+// bloodLabel names a blood relationship n1 generations up from the first
+// individual and n2 down to the second, of the given sex
+func bloodLabel(n1, n2 int, half bool, sex string) string {
+	prefix := ""
+	if half {
+		prefix = "half-"
+	}
+
+	switch {
+	case n1 == 0 && n2 == 0:
+		return "self"
+	case n1 == 0:
+		return grand(n2) + gendered(sex, "son", "daughter", "child")
+	case n2 == 0:
+		return grand(n1) + gendered(sex, "father", "mother", "parent")
+	case n1 == 1 && n2 == 1:
+		return prefix + gendered(sex, "brother", "sister", "sibling")
+	case n1 == 1:
+		return prefix + greats(n2-2) + gendered(sex, "nephew", "niece", "nephew or niece")
+	case n2 == 1:
+		return prefix + greats(n1-2) + gendered(sex, "uncle", "aunt", "uncle or aunt")
+	}
+
+	degree, removed := n1-1, n1-n2
+	if n2 < n1 {
+		degree = n2 - 1
+	} else {
+		removed = n2 - n1
+	}
+	var s string
+	if degree < len(cousinOrdinals) {
+		s = cousinOrdinals[degree] + " cousin"
+	} else {
+		s = ordinal(degree) + " cousin"
+	}
+	if removed < len(removedTimes) {
+		s += removedTimes[removed]
+	} else {
+		s += " " + strconv.Itoa(removed) + " times removed"
+	}
+	return prefix + s
+}
+
+// grand returns the prefix which makes child or parent into grandchild or
+// great-grandparent for n generations
+func grand(n int) string {
+	if n < 2 {
+		return ""
+	}
+	return greats(n-2) + "grand"
+}
+
+// affinityLabel names a relationship by marriage, or returns blank
+func affinityLabel(a, b *IndividualRecord) string {
+	if a == nil || b == nil {
+		return ""
+	}
+	for _, spouse := range a.Spouses() {
+		if spouse == b {
+			return gendered(b.Sex, "husband", "wife", "spouse")
+		}
+	}
+	for _, spouse := range a.Spouses() {
+		for _, child := range spouse.Children() {
+			if child == b {
+				return gendered(b.Sex, "stepson", "stepdaughter", "stepchild")
+			}
+		}
+	}
+	for _, parent := range append(a.Fathers(), a.Mothers()...) {
+		for _, spouse := range parent.Spouses() {
+			if spouse == b {
+				return gendered(b.Sex, "stepfather", "stepmother", "stepparent")
+			}
+			for _, child := range spouse.Children() {
+				if child == b {
+					return gendered(b.Sex, "stepbrother", "stepsister", "stepsibling")
+				}
+			}
+		}
+	}
+
+	// b is a blood relative of a's spouse, or the spouse of a's blood relative
+	for _, spouse := range a.Spouses() {
+		if paths := bloodPaths(spouse, b); len(paths) > 0 {
+			n1, n2 := paths[0].Generations()
+			return inLaw(n1, n2, b.Sex)
+		}
+	}
+	for _, spouse := range b.Spouses() {
+		if paths := bloodPaths(a, spouse); len(paths) > 0 {
+			n1, n2 := paths[0].Generations()
+			return inLaw(n1, n2, b.Sex)
+		}
+	}
+	return ""
+}
+
+// inLaw names a relationship by marriage n1 generations up and n2 down
+func inLaw(n1, n2 int, sex string) string {
+	switch {
+	case n1 == 1 && n2 == 0:
+		return gendered(sex, "father", "mother", "parent") + "-in-law"
+	case n1 == 0 && n2 == 1:
+		return gendered(sex, "son", "daughter", "child") + "-in-law"
+	case n1 == 1 && n2 == 1:
+		return gendered(sex, "brother", "sister", "sibling") + "-in-law"
+	}
+	return "in-law"
+}
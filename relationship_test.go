@@ -0,0 +1,153 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+// relationshipFamilies lists the families of relationshipInput as husband,
+// wife and children
+var relationshipFamilies = [][]string{
+	{"GP1", "GP2", "P1", "P2"},
+	{"P1", "S1", "C1", "C2"},
+	{"P2", "S2", "C3"},
+	{"X", "C3", "D1"},
+	{"GP1", "GP3", "H1"},
+	{"C1", "W1"},
+	{"Y", "W1", "SD1"},
+	{"C1", "C3", "K1"},
+	{"Z", "", "Z1", "Z2"},
+}
+
+var relationshipSexes = map[string]string{
+	"GP1": "M", "GP2": "F", "GP3": "F", "P1": "M", "P2": "F", "S1": "F", "S2": "M",
+	"C1": "M", "C2": "F", "C3": "F", "X": "M", "D1": "M", "H1": "M", "W1": "F",
+	"Y": "M", "SD1": "F", "K1": "M", "Z": "M", "Z1": "M", "Z2": "M",
+}
+
+// relationshipInput builds a GEDCOM file from relationshipFamilies
+func relationshipInput() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("0 HEAD\n")
+	links := make(map[string][]string)
+	for i, fam := range relationshipFamilies {
+		f := "@F" + string(rune('1'+i)) + "@"
+		links[fam[0]] = append(links[fam[0]], "1 FAMS "+f)
+		if fam[1] != "" {
+			links[fam[1]] = append(links[fam[1]], "1 FAMS "+f)
+		}
+		for _, c := range fam[2:] {
+			links[c] = append(links[c], "1 FAMC "+f)
+		}
+	}
+	for _, x := range []string{"GP1", "GP2", "GP3", "P1", "P2", "S1", "S2", "C1", "C2", "C3", "X", "D1", "H1", "W1", "Y", "SD1", "K1", "Z", "Z1", "Z2"} {
+		buf.WriteString("0 @" + x + "@ INDI\n1 SEX " + relationshipSexes[x] + "\n")
+		for _, l := range links[x] {
+			buf.WriteString(l + "\n")
+		}
+	}
+	for i, fam := range relationshipFamilies {
+		buf.WriteString("0 @F" + string(rune('1'+i)) + "@ FAM\n")
+		buf.WriteString("1 HUSB @" + fam[0] + "@\n")
+		if fam[1] != "" {
+			buf.WriteString("1 WIFE @" + fam[1] + "@\n")
+		}
+		for _, c := range fam[2:] {
+			buf.WriteString("1 CHIL @" + c + "@\n")
+		}
+	}
+	buf.WriteString("0 TRLR\n")
+	return buf.Bytes()
+}
+
+func TestRelate(t *testing.T) {
+	d := NewDecoder(bytes.NewReader(relationshipInput()))
+	g, err := d.Decode()
+	if err != nil {
+		t.Fatalf("Decode returned error %v", err)
+	}
+	indi := make(map[string]*IndividualRecord)
+	for _, i := range g.Individual {
+		indi[i.Xref[1:len(i.Xref)-1]] = i
+	}
+
+	examples := []struct {
+		a, b        string
+		label       string
+		coefficient float64
+		ancestors   []string
+	}{
+		{"C1", "C1", "self", 1, []string{"@C1@"}},
+		{"C1", "C2", "sister", 0.5, []string{"@P1@", "@S1@"}},
+		{"C1", "P1", "father", 0.5, []string{"@P1@"}},
+		{"GP1", "D1", "great-grandson", 0.125, []string{"@GP1@"}},
+		{"C1", "C3", "first cousin", 0.125, []string{"@GP1@", "@GP2@"}},
+		{"C1", "D1", "first cousin once removed", 0.0625, []string{"@GP1@", "@GP2@"}},
+		{"C1", "H1", "half-uncle", 0.125, []string{"@GP1@"}},
+		{"H1", "C2", "half-niece", 0.125, []string{"@GP1@"}},
+		{"K1", "P1", "grandfather", 0.375, []string{"@P1@", "@GP1@", "@GP2@"}},
+		{"C1", "W1", "wife", 0, nil},
+		{"C1", "SD1", "stepdaughter", 0, nil},
+		{"SD1", "C1", "stepfather", 0, nil},
+		{"W1", "P1", "father-in-law", 0, nil},
+		{"P1", "W1", "daughter-in-law", 0, nil},
+		{"W1", "C2", "sister-in-law", 0, nil},
+		{"W1", "GP1", "in-law", 0, nil},
+		{"GP3", "S2", "", 0, nil},
+		{"Z1", "Z2", "brother", 0.25, []string{"@Z@"}},
+	}
+
+	for _, ex := range examples {
+		rel := Relate(indi[ex.a], indi[ex.b])
+		if rel.Label != ex.label {
+			t.Errorf("Relate(%s, %s).Label = %q, expected %q", ex.a, ex.b, rel.Label, ex.label)
+		}
+		if rel.Coefficient != ex.coefficient {
+			t.Errorf("Relate(%s, %s).Coefficient = %v, expected %v", ex.a, ex.b, rel.Coefficient, ex.coefficient)
+		}
+		if diff := deep.Equal(xrefs(rel.CommonAncestors), ex.ancestors); diff != nil {
+			t.Errorf("Relate(%s, %s).CommonAncestors: %v", ex.a, ex.b, diff)
+		}
+	}
+
+	rel := Relate(indi["C1"], indi["D1"])
+	if len(rel.Paths) != 2 {
+		t.Fatalf("Relate(C1, D1) found %d paths, expected 2", len(rel.Paths))
+	}
+	up := xrefs(rel.Paths[0].Up)
+	down := xrefs(rel.Paths[0].Down)
+	if diff := deep.Equal([][]string{up, down}, [][]string{{"@C1@", "@P1@", "@GP1@"}, {"@GP1@", "@P2@", "@C3@", "@D1@"}}); diff != nil {
+		t.Errorf("Relate(C1, D1).Paths[0]: %v", diff)
+	}
+}
+
+func TestRelationshipLabels(t *testing.T) {
+	examples := []struct {
+		n1, n2 int
+		half   bool
+		sex    string
+		want   string
+	}{
+		{0, 2, false, "F", "granddaughter"},
+		{5, 0, false, "", "3rd great-grandparent"},
+		{1, 3, false, "M", "great-nephew"},
+		{4, 1, true, "F", "half-great-great-aunt"},
+		{3, 3, false, "M", "second cousin"},
+		{3, 6, false, "F", "second cousin three times removed"},
+		{13, 13, false, "", "12th cousin"},
+		{2, 7, false, "", "first cousin 5 times removed"},
+	}
+
+	for _, ex := range examples {
+		if s := bloodLabel(ex.n1, ex.n2, ex.half, ex.sex); s != ex.want {
+			t.Errorf("bloodLabel(%d, %d, %v, %q) = %q, expected %q", ex.n1, ex.n2, ex.half, ex.sex, s, ex.want)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"reflect"
+)
+
+// Resolve links every FamilyLink, CitationRecord, NOTE pointer,
+// IndividualLink, RoleRecord, MediaLink, RepositoryLink, SubmitterLink and
+// SubmissionLink in the tree to the level 0 record with its xref. Decode
+// calls it; call it again after adding, removing or replacing records.
+// Links to an xref which is not defined, perhaps because its record was
+// removed, are cleared: the unexported links and MediaLink.Media become
+// nil, and the other links point to a new record with only the xref. The
+// xrefs are returned in the order they were found.
+func (r *RootRecord) Resolve() (dangling []string) {
+	targets := r.targets()
+	missing := make(map[string]bool)
+	miss := func(xref string) {
+		if !missing[xref] {
+			missing[xref] = true
+			dangling = append(dangling, xref)
+		}
+	}
+
+	walk(reflect.ValueOf(r), make(map[uintptr]bool), func(v interface{}) {
+		switch x := v.(type) {
+		case *FamilyLink:
+			f, ok := targets[x.Value].(*FamilyRecord)
+			x.family = f
+			if !ok && isPointer(x.Value) {
+				miss(x.Value)
+			}
+
+		case *CitationRecord:
+			s, ok := targets[x.Value].(*SourceRecord)
+			x.source = s
+			if !ok && isPointer(x.Value) {
+				miss(x.Value)
+			}
+
+		case *NoteRecord:
+			if x.Xref != "" || !isPointer(x.Note) {
+				break
+			}
+			n, ok := targets[x.Note].(*NoteRecord)
+			x.target = n
+			if !ok {
+				miss(x.Note)
+			}
+
+		case *IndividualLink:
+			if x.Individual == nil || x.Individual.Xref == "" {
+				break
+			}
+			if i, ok := targets[x.Individual.Xref].(*IndividualRecord); ok {
+				x.Individual = i
+			} else {
+				miss(x.Individual.Xref)
+				x.Individual = &IndividualRecord{Xref: x.Individual.Xref}
+			}
+
+		case *RoleRecord:
+			if x.Individual == nil || x.Individual.Xref == "" {
+				break
+			}
+			if i, ok := targets[x.Individual.Xref].(*IndividualRecord); ok {
+				x.Individual = i
+			} else {
+				miss(x.Individual.Xref)
+				x.Individual = &IndividualRecord{Xref: x.Individual.Xref}
+			}
+
+		case *MediaLink:
+			if !isPointer(x.Value) {
+				break
+			}
+			m, ok := targets[x.Value].(*MediaRecord)
+			x.Media = m
+			if !ok {
+				miss(x.Value)
+			}
+
+		case *RepositoryLink:
+			if x.Repository == nil || x.Repository.Xref == "" {
+				break
+			}
+			if repo, ok := targets[x.Repository.Xref].(*RepositoryRecord); ok {
+				x.Repository = repo
+			} else {
+				miss(x.Repository.Xref)
+				x.Repository = &RepositoryRecord{Xref: x.Repository.Xref}
+			}
+
+		case *SubmitterLink:
+			if x.Submitter == nil || x.Submitter.Xref == "" {
+				break
+			}
+			if subm, ok := targets[x.Submitter.Xref].(*SubmitterRecord); ok {
+				x.Submitter = subm
+			} else {
+				miss(x.Submitter.Xref)
+				x.Submitter = &SubmitterRecord{Xref: x.Submitter.Xref}
+			}
+
+		case *SubmissionLink:
+			if x.Submission == nil || x.Submission.Xref == "" {
+				break
+			}
+			if subn, ok := targets[x.Submission.Xref].(*SubmissionRecord); ok {
+				x.Submission = subn
+			} else {
+				miss(x.Submission.Xref)
+				x.Submission = &SubmissionRecord{Xref: x.Submission.Xref}
+			}
+		}
+	})
+
+	return dangling
+}
+
+// targets returns the level 0 records which links point to, by xref. The
+// first of several records with the same xref is used.
+func (r *RootRecord) targets() map[string]interface{} {
+	targets := make(map[string]interface{})
+	add := func(xref string, rec interface{}) {
+		if _, found := targets[xref]; xref != "" && !found {
+			targets[xref] = rec
+		}
+	}
+
+	for _, x := range r.Individual {
+		add(x.Xref, x)
+	}
+	for _, x := range r.Family {
+		add(x.Xref, x)
+	}
+	for _, x := range r.Source {
+		add(x.Xref, x)
+	}
+	for _, x := range r.Note {
+		add(x.Xref, x)
+	}
+	for _, x := range r.Media {
+		add(x.Xref, x)
+	}
+	for _, x := range r.Repository {
+		add(x.Xref, x)
+	}
+	for _, x := range r.Submitter {
+		add(x.Xref, x)
+	}
+	for _, x := range r.Submission {
+		add(x.Xref, x)
+	}
+
+	return targets
+}
+
+// walk calls visit once with each pointer to a struct which can be
+// reached from v through exported fields, slices and pointers
+func walk(v reflect.Value, visited map[uintptr]bool, visit func(interface{})) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || v.Elem().Kind() != reflect.Struct || visited[v.Pointer()] {
+			return
+		}
+		visited[v.Pointer()] = true
+		visit(v.Interface())
+		walk(v.Elem(), visited, visit)
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if t.Field(i).PkgPath == "" { // exported
+				walk(v.Field(i), visited, visit)
+			}
+		}
+
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			walk(v.Index(i), visited, visit)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestResolveDecoded(t *testing.T) {
+	input := `0 HEAD
+1 SUBM @U1@
+0 @I1@ INDI
+1 FAMS @F1@
+1 SOUR @S1@
+1 NOTE @N1@
+1 OBJE @M1@
+0 @F1@ FAM
+1 HUSB @I1@
+1 WIFE @I999@
+0 @S1@ SOUR
+1 REPO @R1@
+0 @N1@ NOTE A shared note
+0 @M1@ OBJE
+1 FILE photo.jpg
+0 @R1@ REPO
+1 NAME Archive
+0 @U1@ SUBM
+1 NAME Submitter
+0 TRLR
+`
+	d := NewDecoder(bytes.NewReader([]byte(input)))
+	g, err := d.Decode()
+	if err != nil {
+		t.Fatalf("Decode returned error %v", err)
+	}
+	d = nil // links must not need the decoder
+
+	i1 := g.Individual[0]
+	if f := i1.Family[0].GetFamily(d); f != g.Family[0] {
+		t.Errorf("FAMS links to %v, expected %v", f, g.Family[0])
+	}
+	if s := i1.Citation[0].Source(d); s != g.Source[0] {
+		t.Errorf("SOUR links to %v, expected %v", s, g.Source[0])
+	}
+	if n := i1.Note[0].Target(); n != g.Note[0] {
+		t.Errorf("NOTE links to %v, expected %v", n, g.Note[0])
+	}
+	if m := i1.Media[0].Media; m != g.Media[0] {
+		t.Errorf("OBJE links to %v, expected %v", m, g.Media[0])
+	}
+	if repo := g.Source[0].Repository.Repository; repo != g.Repository[0] {
+		t.Errorf("REPO links to %v, expected %v", repo, g.Repository[0])
+	}
+	if subm := g.Header.Submitter[0].Submitter; subm != g.Submitter[0] {
+		t.Errorf("SUBM links to %v, expected %v", subm, g.Submitter[0])
+	}
+	if diff := deep.Equal(g.Resolve(), []string{"@I999@"}); diff != nil {
+		t.Errorf("Resolve: %v", diff)
+	}
+}
+
+func TestResolveBuilt(t *testing.T) {
+	fam := &FamilyRecord{Xref: "@F1@"}
+	src := &SourceRecord{Xref: "@S1@"}
+	husband := &IndividualRecord{Xref: "@I1@"}
+	indi := &IndividualRecord{
+		Xref:     "@I1@",
+		Family:   FamilyLinks{{Level: 1, Tag: "FAMS", Value: "@F1@"}},
+		Parents:  FamilyLinks{{Level: 1, Tag: "FAMC", Value: "@F2@"}},
+		Citation: CitationRecords{{Level: 1, Value: "@S1@"}, {Level: 1, Value: "a book"}},
+	}
+	fam.Husband = &IndividualLink{Level: 1, Tag: "HUSB", Individual: husband}
+	g := &RootRecord{
+		Individual: IndividualRecords{indi},
+		Family:     FamilyRecords{fam},
+		Source:     SourceRecords{src},
+	}
+
+	if diff := deep.Equal(g.Resolve(), []string{"@F2@"}); diff != nil {
+		t.Errorf("Resolve: %v", diff)
+	}
+	if indi.Family[0].Family() != fam {
+		t.Errorf("FAMS was not linked")
+	}
+	if indi.Parents[0].Family() != nil {
+		t.Errorf("FAMC to an undefined family was linked")
+	}
+	if indi.Citation[0].Source(nil) != src || indi.Citation[1].Source(nil) != nil {
+		t.Errorf("SOUR was not linked")
+	}
+	if fam.Husband.Individual != indi {
+		t.Errorf("HUSB links to %p, expected the record %p", fam.Husband.Individual, indi)
+	}
+}
+
+func TestResolveRemoved(t *testing.T) {
+	input := `0 HEAD
+0 @I1@ INDI
+1 FAMC @F1@
+1 FAMS @F2@
+1 SOUR @S1@
+1 NOTE @N1@
+1 OBJE @M1@
+0 @I2@ INDI
+1 FAMS @F1@
+0 @F1@ FAM
+1 HUSB @I2@
+1 CHIL @I1@
+0 @F2@ FAM
+1 HUSB @I1@
+0 @S1@ SOUR
+0 @N1@ NOTE A shared note
+0 @M1@ OBJE
+1 FILE photo.jpg
+0 TRLR
+`
+	g, err := NewDecoder(bytes.NewReader([]byte(input))).Decode()
+	if err != nil {
+		t.Fatalf("Decode returned error %v", err)
+	}
+
+	g.Individual = g.Individual[:1]
+	g.Family = g.Family[:1]
+	g.Source = nil
+	g.Note = nil
+	g.Media = nil
+	dangling := g.Resolve()
+	sort.Strings(dangling)
+	if diff := deep.Equal(dangling, []string{"@F2@", "@I2@", "@M1@", "@N1@", "@S1@"}); diff != nil {
+		t.Errorf("Resolve: %v", diff)
+	}
+
+	i1 := g.Individual[0]
+	if f := i1.Parents[0].Family(); f != g.Family[0] {
+		t.Errorf("FAMC links to %v, expected %v", f, g.Family[0])
+	}
+	if f := i1.Family[0].Family(); f != nil {
+		t.Errorf("FAMS links to the removed %v", f)
+	}
+	if s := i1.Citation[0].Source(nil); s != nil {
+		t.Errorf("SOUR links to the removed %v", s)
+	}
+	if n := i1.Note[0].Target(); n != nil {
+		t.Errorf("NOTE links to the removed %v", n)
+	}
+	if m := i1.Media[0].Media; m != nil {
+		t.Errorf("OBJE links to the removed %v", m)
+	}
+	if husband := g.Family[0].Husband.Individual; husband.Xref != "@I2@" || len(husband.Family) != 0 {
+		t.Errorf("HUSB links to %v, expected a record with only the xref @I2@", husband)
+	}
+}
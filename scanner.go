@@ -6,22 +6,26 @@ information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
 package gedcom
 
 import (
+	"bufio"
 	"fmt"
 	"io"
-	"log"
-	"strconv"
 )
 
 // A scanner is a GEDCOM scanning state machine.
 type scanner struct {
 	parseState int
 	tokenStart int
+	lineStart  int    // index of the level within the data
 	level      int    // the level
 	tag        []byte // tag
 	value      []byte // value
 	xref       []byte // xref (between level and tag)
+	ref        []byte // xref with its @s
 }
 
+// maxLevel is more than the level of any sensible line
+const maxLevel = 1 << 20
+
 const (
 	stateBegin = iota
 	stateLevel
@@ -38,10 +42,25 @@ const (
 func (s *scanner) reset() {
 	s.parseState = stateBegin
 	s.tokenStart = 0
+	s.lineStart = 0
 	s.level = 0
-	s.xref = make([]byte, 0)
-	s.tag = make([]byte, 0)
-	s.value = make([]byte, 0)
+	s.xref = nil
+	s.ref = nil
+	s.tag = nil
+	s.value = nil
+}
+
+// fail puts the scanner in the error state and returns the offset of the
+// end of the bad line, so that scanning can resume with the next line.
+// If the end of the line is not in data, io.EOF asks for more data.
+func (s *scanner) fail(data []byte, i int, err error) (offset int, ferr error) {
+	s.parseState = stateError
+	for ; i < len(data); i++ {
+		if data[i] == '\n' || data[i] == '\r' {
+			return i, err
+		}
+	}
+	return 0, io.EOF
 }
 
 func (s *scanner) nextTag(data []byte) (offset int, err error) {
@@ -52,33 +71,30 @@ func (s *scanner) nextTag(data []byte) (offset int, err error) {
 			switch {
 			case c >= '0' && c <= '9':
 				s.tokenStart = i
+				s.lineStart = i
 				s.parseState = stateLevel
 			case isSpace(c):
 				continue
 			default:
-				s.parseState = stateError
-				err = fmt.Errorf("Found non-whitespace before level")
-				log.Println(err.Error())
-				return
+				s.lineStart = i
+				return s.fail(data, i, fmt.Errorf("Found non-whitespace before level"))
 			}
 		case stateLevel:
 			switch {
 			case c >= '0' && c <= '9':
 				continue
 			case c == ' ':
-				parsedLevel, perr := strconv.ParseInt(string(data[s.tokenStart:i]), 10, 64)
-				if perr != nil {
-					err = perr
-					log.Println(err.Error())
-					return
+				level := 0
+				for _, d := range data[s.tokenStart:i] {
+					if level > maxLevel/10 {
+						return s.fail(data, i, fmt.Errorf("Level %s out of range", data[s.tokenStart:i]))
+					}
+					level = level*10 + int(d-'0')
 				}
-				s.level = int(parsedLevel)
+				s.level = level
 				s.parseState = stateSeekTagOrXref
 			default:
-				s.parseState = stateError
-				err = fmt.Errorf("Level contained non-numerics")
-				log.Println(err.Error())
-				return
+				return s.fail(data, i, fmt.Errorf("Level contained non-numerics"))
 			}
 
 		case stateSeekTag:
@@ -89,10 +105,7 @@ func (s *scanner) nextTag(data []byte) (offset int, err error) {
 			case c == ' ':
 				continue
 			default:
-				s.parseState = stateError
-				err = fmt.Errorf("Tag \"%s\" contained non-alphanumeric", string(data[s.tokenStart:i]))
-				log.Println(err.Error())
-				return
+				return s.fail(data, i, fmt.Errorf("Tag \"%s\" contained non-alphanumeric", string(data[s.tokenStart:i])))
 			}
 		case stateSeekTagOrXref:
 			switch {
@@ -105,10 +118,7 @@ func (s *scanner) nextTag(data []byte) (offset int, err error) {
 			case c == ' ':
 				continue
 			default:
-				s.parseState = stateError
-				err = fmt.Errorf("Xref \"%s\" contained non-alphanumeric", string(data[s.tokenStart:i]))
-				log.Println(err.Error())
-				return
+				return s.fail(data, i, fmt.Errorf("Xref \"%s\" contained non-alphanumeric", string(data[s.tokenStart:i])))
 			}
 
 		case stateTag:
@@ -124,10 +134,7 @@ func (s *scanner) nextTag(data []byte) (offset int, err error) {
 				s.tag = data[s.tokenStart:i]
 				s.parseState = stateSeekValue
 			default:
-				s.parseState = stateError
-				err = fmt.Errorf("Tag contained non-alphanumeric")
-				log.Println(err.Error())
-				return
+				return s.fail(data, i, fmt.Errorf("Tag contained non-alphanumeric"))
 			}
 
 		case stateXref:
@@ -135,13 +142,14 @@ func (s *scanner) nextTag(data []byte) (offset int, err error) {
 			case isAlphaNumeric(c) || c == '@':
 				continue
 			case c == ' ':
+				if i-1 <= s.tokenStart+1 || data[i-1] != '@' {
+					return s.fail(data, i, fmt.Errorf("Xref \"%s\" is not enclosed in @s", string(data[s.tokenStart:i])))
+				}
+				s.ref = data[s.tokenStart:i]
 				s.xref = data[s.tokenStart+1 : i-1]
 				s.parseState = stateSeekTag
 			default:
-				s.parseState = stateError
-				err = fmt.Errorf("Xref contained non-alphanumeric")
-				log.Println(err.Error())
-				return
+				return s.fail(data, i, fmt.Errorf("Xref contained non-alphanumeric"))
 			}
 		case stateSeekValue:
 			switch {
@@ -171,7 +179,7 @@ func (s *scanner) nextTag(data []byte) (offset int, err error) {
 			break
 
 		default:
-			panic("what state are we in?")
+			return s.fail(data, i, fmt.Errorf("Scanner in unknown state %d", s.parseState))
 		}
 	}
 
@@ -185,3 +193,143 @@ func isSpace(c byte) bool {
 func isAlphaNumeric(c byte) bool {
 	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
 }
+
+// maxTags limits the tags interned by a lineReader
+const maxTags = 1024
+
+// A lineReader scans the lines of GEDCOM input, reading it as needed.
+// Lines are scanned in the buffer of r, or in long if they do not fit, so
+// the scanned tag, value and xref are only valid until the next line.
+type lineReader struct {
+	r       *bufio.Reader
+	s       scanner
+	long    []byte            // a line longer than the buffer of r
+	tags    map[string]string // interned tags
+	base    int64             // byte offset of the buffered input
+	lineNum int               // number of the last line, starting at 1
+	offset  int64             // byte offset in the input of the start of the last line
+	cr      *charsetReader    // r converts the input, if not nil
+}
+
+// newLineReader returns a lineReader of r
+func newLineReader(r io.Reader) *lineReader {
+	cr, _ := r.(*charsetReader)
+	return &lineReader{r: bufio.NewReaderSize(r, 4096), tags: make(map[string]string), cr: cr}
+}
+
+// setOffset sets the offset of the last line from its offset in the
+// UTF-8 read from r
+func (l *lineReader) setOffset(offset int64) {
+	if l.cr != nil {
+		offset = l.cr.inputOffset(offset)
+	}
+	l.offset = offset
+}
+
+// discard drops n bytes of buffered input
+func (l *lineReader) discard(n int) {
+	l.r.Discard(n)
+	l.base += int64(n)
+}
+
+// next scans the next line into l.s. Lines may be indented and end with
+// LF, CR, CRLF or LFCR, or with the end of the input; blank lines are
+// skipped. A malformed line is counted and returned as a syntax error. At the end of the input err is io.EOF;
+// otherwise it is the error from reading.
+func (l *lineReader) next() (syntax error, err error) {
+	buf, err := l.r.Peek(1)
+	for err == nil {
+		buf, _ = l.r.Peek(l.r.Buffered())
+		l.s.reset()
+		offset, serr := l.s.nextTag(buf)
+		if serr != io.EOF {
+			l.lineNum++
+			l.setOffset(l.base + int64(l.s.lineStart))
+			l.discard(offset)
+			return serr, nil
+		}
+		if len(buf) == l.r.Size() {
+			return l.nextLong(buf)
+		}
+		// read more, keeping what is buffered
+		_, err = l.r.Peek(len(buf) + 1)
+	}
+	if err != io.EOF {
+		return nil, err
+	}
+
+	// the last line may have no terminator
+	start := l.base
+	buf, _ = l.r.Peek(l.r.Buffered())
+	l.long = append(append(l.long[:0], buf...), '\n')
+	l.discard(len(buf))
+	return l.scan(l.long, start)
+}
+
+// nextLong scans a line which does not fit in the buffer of r, of which
+// buf is the start
+func (l *lineReader) nextLong(buf []byte) (syntax error, err error) {
+	start := l.base
+	l.long = append(l.long[:0], buf...)
+	l.discard(len(buf))
+	for {
+		if _, err = l.r.Peek(1); err != nil {
+			if err != io.EOF {
+				return nil, err
+			}
+			// the last line may have no terminator
+			return l.scan(append(l.long, '\n'), start)
+		}
+		buf, _ = l.r.Peek(l.r.Buffered())
+		end := len(buf)
+		for i, c := range buf {
+			if c == '\n' || c == '\r' {
+				end = i + 1
+				break
+			}
+		}
+		l.long = append(l.long, buf[:end]...)
+		l.discard(end)
+		if end == len(buf) && buf[end-1] != '\n' && buf[end-1] != '\r' {
+			continue
+		}
+
+		if syntax, err = l.scan(l.long, start); err != io.EOF {
+			return syntax, err
+		}
+		// only blank lines so far
+		start = l.base
+		l.long = l.long[:0]
+	}
+}
+
+// scan scans line, which starts at byte offset start of the input and
+// ends with its terminator. If it is blank err is io.EOF.
+func (l *lineReader) scan(line []byte, start int64) (syntax error, err error) {
+	l.s.reset()
+	if _, serr := l.s.nextTag(line); serr != io.EOF {
+		l.lineNum++
+		l.setOffset(start + int64(l.s.lineStart))
+		return serr, nil
+	}
+	return nil, io.EOF
+}
+
+// tag returns the tag of the last line, interned so that the common tags
+// are not allocated for every line
+func (l *lineReader) tag() string {
+	if tag, found := l.tags[string(l.s.tag)]; found {
+		return tag
+	}
+	tag := string(l.s.tag)
+	if len(l.tags) < maxTags {
+		l.tags[tag] = tag
+	}
+	return tag
+}
+
+// value returns the value of the last line, with each @@ in text replaced
+// by @
+func (l *lineReader) value() string {
+	return unescapeValue(string(l.s.value))
+}
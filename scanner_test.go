@@ -6,8 +6,14 @@ information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
 package gedcom
 
 import (
+	"bytes"
 	"io"
+	"io/ioutil"
+	"runtime"
+	"strings"
 	"testing"
+
+	"github.com/go-test/deep"
 )
 
 type example struct {
@@ -80,6 +86,38 @@ var examplesNot = [][]byte{
 	[]byte(" 1 SEX F "),
 }
 
+var examplesMalformed = [][]byte{
+	[]byte("0 @ INDI\n"),
+	[]byte("0 @@ INDI\n"),
+	[]byte("0 @I1 INDI\n"),
+	[]byte("0 X1@ INDI\n"),
+	[]byte("X HEAD\n"),
+}
+
+func TestNextTagMalformed(t *testing.T) {
+	s := &scanner{}
+	for _, ex := range examplesMalformed {
+		s.reset()
+		offset, err := s.nextTag(ex)
+
+		if err == nil || err == io.EOF {
+			t.Errorf(`nextTag for "%s" returned error %v, expected a syntax error`, ex, err)
+		}
+		if offset != len(ex)-1 {
+			t.Errorf(`nextTag for "%s" returned offset %d, expected %d`, ex, offset, len(ex)-1)
+		}
+	}
+
+	// scanning goes on after a malformed line
+	lr := newLineReader(bytes.NewReader([]byte("0 @ INDI\n1 NAME x\n")))
+	if serr, err := lr.next(); serr == nil || err != nil {
+		t.Errorf("next returned %v, %v, expected a syntax error", serr, err)
+	}
+	if serr, err := lr.next(); serr != nil || err != nil || lr.tag() != "NAME" || lr.lineNum != 2 {
+		t.Errorf("next returned %v, %v at line %d %s, expected NAME at line 2", serr, err, lr.lineNum, lr.tag())
+	}
+}
+
 func TestNextTagNotFound(t *testing.T) {
 	s := &scanner{}
 	for _, ex := range examplesNot {
@@ -93,3 +131,162 @@ func TestNextTagNotFound(t *testing.T) {
 	}
 
 }
+
+func TestLineReaderLong(t *testing.T) {
+	long := strings.Repeat("x", 10000)
+	input := "0 HEAD\n" + strings.Repeat(" \r\n", 3000) + "1 NOTE " + long + "\r\n2 CONC y\n"
+	lr := newLineReader(strings.NewReader(input))
+
+	expected := []example{
+		{nil, 0, "HEAD", "", ""},
+		{nil, 1, "NOTE", long, ""},
+		{nil, 2, "CONC", "y", ""},
+	}
+	offsets := []int64{0, int64(strings.Index(input, "1 NOTE")), int64(strings.Index(input, "2 CONC"))}
+	for i, ex := range expected {
+		serr, err := lr.next()
+		if serr != nil || err != nil {
+			t.Fatalf("next for line %d returned %v, %v", i+1, serr, err)
+		}
+		if lr.s.level != ex.level || lr.tag() != ex.tag || string(lr.s.value) != ex.value {
+			t.Errorf("next for line %d returned %d %s %.20q, expected %d %s %.20q", i+1, lr.s.level, lr.tag(), lr.s.value, ex.level, ex.tag, ex.value)
+		}
+		if lr.lineNum != i+1 || lr.offset != offsets[i] {
+			t.Errorf("next for line %d returned line %d at %d, expected line %d at %d", i+1, lr.lineNum, lr.offset, i+1, offsets[i])
+		}
+	}
+	if _, err := lr.next(); err != io.EOF {
+		t.Errorf("next at the end returned %v, expected io.EOF", err)
+	}
+}
+
+func TestLineReaderTerminators(t *testing.T) {
+	long := strings.Repeat("x", 5000)
+	lines := []string{"0 HEAD", "1 CHAR UTF-8", "0 @N1@ NOTE " + long, "1 CONC y", "0 TRLR"}
+	expected := []example{
+		{nil, 0, "HEAD", "", ""},
+		{nil, 1, "CHAR", "UTF-8", ""},
+		{nil, 0, "NOTE", long, "N1"},
+		{nil, 1, "CONC", "y", ""},
+		{nil, 0, "TRLR", "", ""},
+	}
+
+	inputs := map[string]string{
+		"LF":           strings.Join(lines, "\n") + "\n",
+		"CR":           strings.Join(lines, "\r") + "\r",
+		"CRLF":         strings.Join(lines, "\r\n") + "\r\n",
+		"LFCR":         strings.Join(lines, "\n\r") + "\n\r",
+		"unterminated": strings.Join(lines, "\n"),
+		"blank":        strings.Join(lines, "\n\n \n") + "\n\t\n",
+		"indented":     "0 HEAD\n  1 CHAR UTF-8\n0 @N1@ NOTE " + long + "\n\t1 CONC y\n0 TRLR",
+	}
+	for name, input := range inputs {
+		lr := newLineReader(strings.NewReader(input))
+		for i, ex := range expected {
+			serr, err := lr.next()
+			if serr != nil || err != nil {
+				t.Fatalf("%s: next for line %d returned %v, %v", name, i+1, serr, err)
+			}
+			if lr.s.level != ex.level || lr.tag() != ex.tag || string(lr.s.value) != ex.value || string(lr.s.xref) != ex.xref {
+				t.Errorf("%s: next for line %d returned %d %s %.20q %s, expected %d %s %.20q %s", name, i+1, lr.s.level, lr.tag(), lr.s.value, lr.s.xref, ex.level, ex.tag, ex.value, ex.xref)
+			}
+		}
+		if _, err := lr.next(); err != io.EOF {
+			t.Errorf("%s: next at the end returned %v, expected io.EOF", name, err)
+		}
+	}
+}
+
+func TestDecodeTerminators(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/kennedy.ged")
+	if err != nil {
+		t.Fatalf("Cannot read testdata/kennedy.ged: %v", err)
+	}
+	g, err := NewDecoder(bytes.NewReader(data)).Decode()
+	if err != nil {
+		t.Fatalf("Decode returned %v", err)
+	}
+	written := g.String() // indented, LF terminated
+
+	inputs := map[string]string{
+		"CR":           strings.Replace(written, "\n", "\r", -1),
+		"CRLF":         strings.Replace(written, "\n", "\r\n", -1),
+		"LFCR":         strings.Replace(written, "\n", "\n\r", -1),
+		"unterminated": strings.TrimSuffix(written, "\n"),
+	}
+	for name, input := range inputs {
+		g2, err := NewDecoder(strings.NewReader(input)).Decode()
+		if err != nil {
+			t.Fatalf("%s: Decode returned %v", name, err)
+		}
+		if s := g2.String(); s != written {
+			t.Errorf("%s: Decode differs: %v", name, deep.Equal(strings.Split(s, "\n"), strings.Split(written, "\n")))
+		}
+	}
+}
+
+func TestLineReaderAllocs(t *testing.T) {
+	input := []byte(strings.Repeat("0 @I1@ INDI\n1 NAME John /Smith/\n1 SEX M\n1 BIRT\n2 DATE 1 JAN 1900\n", 100))
+	d := NewDecoder(nil)
+	lr := newLineReader(bytes.NewReader(nil))
+	allocs := testing.AllocsPerRun(10, func() {
+		lr.r.Reset(bytes.NewReader(input))
+		for {
+			if _, err := d.nextLine(lr); err != nil {
+				break
+			}
+		}
+	})
+
+	// one string for each xref and each value, none for tags
+	if max := float64(100 * 4); allocs > max {
+		t.Errorf("reading 500 lines made %.0f allocations, expected at most %.0f", allocs, max)
+	}
+}
+
+// benchmarkLines reads the lines of a file b.N times, reporting the heap
+// allocations per line
+func benchmarkLines(b *testing.B, name string) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		b.Fatalf("Cannot read %s: %v", name, err)
+	}
+
+	var before, after runtime.MemStats
+	lines := 0
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	runtime.ReadMemStats(&before)
+	for i := 0; i < b.N; i++ {
+		d := NewDecoder(nil)
+		lr := newLineReader(d.charsetReader(bytes.NewReader(data)))
+		for {
+			if _, err := d.nextLine(lr); err != nil {
+				break
+			}
+			lines++
+		}
+	}
+	runtime.ReadMemStats(&after)
+	b.ReportMetric(float64(after.Mallocs-before.Mallocs)/float64(lines), "allocs/line")
+}
+
+func BenchmarkLinesKennedy(b *testing.B) { benchmarkLines(b, "testdata/kennedy.ged") }
+
+func BenchmarkLinesAllged(b *testing.B) { benchmarkLines(b, "testdata/allged.ged") }
+
+func BenchmarkDecodeKennedy(b *testing.B) {
+	data, err := ioutil.ReadFile("testdata/kennedy.ged")
+	if err != nil {
+		b.Fatalf("Cannot read testdata/kennedy.ged: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		if _, err := NewDecoder(bytes.NewReader(data)).Decode(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
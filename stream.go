@@ -0,0 +1,163 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"io"
+)
+
+// decodeStream holds the state of a Decoder between calls to Next
+type decodeStream struct {
+	lr      *lineReader
+	root    *RootRecord // holds the record being decoded
+	pending *line       // level 0 line which starts the next record
+	open    bool        // a record is being decoded
+	ready   []Record    // decoded records not yet returned
+	done    bool        // the input is finished
+}
+
+// take removes and returns the level 0 records of the RootRecord
+func (r *RootRecord) take() []Record {
+	var recs []Record
+	if r.Header != nil {
+		recs = append(recs, r.Header)
+	}
+	for _, x := range r.Publish_ {
+		recs = append(recs, x)
+	}
+	recs = append(recs, r.records()...)
+	for _, x := range r.PlaceDefinition_ {
+		recs = append(recs, x)
+	}
+	for _, x := range r.EventDefinition_ {
+		recs = append(recs, x)
+	}
+	for _, x := range r.ChildStatus {
+		recs = append(recs, x)
+	}
+	for _, x := range r.Todo_ {
+		recs = append(recs, x)
+	}
+	for _, x := range r.Album {
+		recs = append(recs, x)
+	}
+	if len(r.UnknownTags) > 0 {
+		recs = append(recs, r.UnknownTags)
+	}
+	if r.Trailer != nil {
+		recs = append(recs, r.Trailer)
+	}
+
+	*r = RootRecord{Level: r.Level}
+	return recs
+}
+
+// Next decodes and returns the next level 0 record of the input, like an
+// *IndividualRecord, a *FamilyRecord or a *HeaderRecord, and then forgets
+// it, so that files of any size can be read a record at a time. Lines at
+// level 0 which are not understood are returned as RawLines. At the end of
+// the input Next returns io.EOF.
+//
+// Pointers are not linked to records, as the records they point to may
+// not have been read, so the links of a record only have their xrefs. With
+// DecoderOptions.KeepXrefs the Decoder keeps the xref of each definition
+// and pointer for Integrity. Next and Decode should not both be used with
+// one Decoder.
+func (d *Decoder) Next() (Record, error) {
+	if d.stream == nil {
+		d.startStream()
+	}
+	st := d.stream
+
+	for len(st.ready) == 0 {
+		if st.done {
+			return nil, io.EOF
+		}
+
+		var l line
+		if st.pending != nil {
+			l, st.pending = *st.pending, nil
+		} else {
+			var err error
+			l, err = d.nextLine(st.lr)
+			if err == io.EOF {
+				st.done = true
+				st.ready = d.finishRecord()
+				continue
+			}
+			if err != nil {
+				st.done = true
+				return nil, err
+			}
+		}
+
+		if l.level == 0 && st.open {
+			st.pending = &l
+			st.ready = d.finishRecord()
+			continue
+		}
+		if err := d.parseLine(l, d.opts.KeepXrefs); err != nil {
+			st.done = true
+			return nil, err
+		}
+		st.open = true
+	}
+
+	rec := st.ready[0]
+	st.ready = st.ready[1:]
+	return rec, nil
+}
+
+// startStream prepares the Decoder for Next
+func (d *Decoder) startStream() {
+	d.stream = &decodeStream{root: &RootRecord{Level: -1}}
+	if d.opts.KeepXrefs {
+		d.xrefs = &xrefTable{}
+	}
+	d.refs = make(map[string]interface{})
+	d.parsers = []parser{makeRootParser(d, d.stream.root)}
+	d.prevLevel = -1
+	d.input = &countingReader{r: d.r}
+	d.stream.lr = newLineReader(d.charsetReader(d.input))
+}
+
+// finishRecord returns the records decoded since the last level 0 line
+// and forgets the records they point to
+func (d *Decoder) finishRecord() []Record {
+	st := d.stream
+	if st.open {
+		d.records++
+	}
+	if st.open || st.done {
+		d.progress()
+	}
+	st.open = false
+	d.parsers = d.parsers[:1]
+	for xref := range d.refs {
+		delete(d.refs, xref)
+	}
+
+	recs := st.root.take()
+	if d.opts.FillNames {
+		for _, rec := range recs {
+			if indi, ok := rec.(*IndividualRecord); ok {
+				for _, name := range indi.Name {
+					name.Fill()
+				}
+			}
+		}
+	}
+	return recs
+}
+
+// Integrity checks the xrefs read by Next with DecoderOptions.KeepXrefs,
+// as RootRecord.Integrity does for a decoded tree
+func (d *Decoder) Integrity() *IntegrityReport {
+	if d.stream == nil || d.xrefs == nil {
+		return &IntegrityReport{}
+	}
+	return d.xrefs.report()
+}
@@ -0,0 +1,127 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestNext(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/kennedy.ged")
+	if err != nil {
+		t.Fatalf("Cannot read test data: %v", err)
+	}
+
+	g, err := NewDecoder(bytes.NewReader(data)).Decode()
+	if err != nil {
+		t.Fatalf("Decode returned error %v", err)
+	}
+	wantIndis, wantFams := len(g.Individual), len(g.Family)
+	var want []string
+	for _, rec := range g.take() {
+		want = append(want, rec.String())
+	}
+
+	d := NewDecoder(bytes.NewReader(data))
+	var got []string
+	var indis, fams int
+	for {
+		rec, err := d.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next returned error %v", err)
+		}
+		switch rec.(type) {
+		case *IndividualRecord:
+			indis++
+		case *FamilyRecord:
+			fams++
+		}
+		got = append(got, rec.String())
+	}
+
+	if indis != wantIndis || fams != wantFams {
+		t.Errorf("Next returned %d individuals and %d families, expected %d and %d", indis, fams, wantIndis, wantFams)
+	}
+	if rec, err := d.Next(); rec != nil || err != io.EOF {
+		t.Errorf("Next after the end returned %v, %v", rec, err)
+	}
+
+	// Decode groups the records by type, Next returns them in input order
+	count := make(map[string]int)
+	for _, s := range want {
+		count[s]++
+	}
+	for _, s := range got {
+		count[s]--
+	}
+	for s, n := range count {
+		if n != 0 {
+			t.Errorf("Next and Decode differ by %d for:\n%s", n, s)
+		}
+	}
+}
+
+var nextInput = `0 HEAD
+0 @I1@ INDI
+1 FAMS @F1@
+1 _CUSTOM kept
+0 @F1@ FAM
+1 HUSB @I1@
+1 CHIL @I2@
+0 _LOOSE line
+1 _MORE lines
+0 TRLR
+`
+
+func TestNextKeepXrefs(t *testing.T) {
+	d := NewDecoderWithOptions(bytes.NewReader([]byte(nextInput)), DecoderOptions{KeepXrefs: true})
+
+	var tags []string
+	for {
+		rec, err := d.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next returned error %v", err)
+		}
+		switch r := rec.(type) {
+		case *HeaderRecord:
+			tags = append(tags, "HEAD")
+		case *IndividualRecord:
+			tags = append(tags, "INDI "+r.Xref)
+			if len(r.UnknownTags) != 1 {
+				t.Errorf("INDI has %d unknown tags, expected 1", len(r.UnknownTags))
+			}
+		case *FamilyRecord:
+			tags = append(tags, "FAM "+r.Xref)
+			if r.Husband == nil || r.Husband.Individual == nil || r.Husband.Individual.Xref != "@I1@" {
+				t.Errorf("FAM HUSB = %v", r.Husband)
+			}
+		case RawLines:
+			tags = append(tags, r[0].Tag)
+		case *TrailerRecord:
+			tags = append(tags, "TRLR")
+		}
+	}
+
+	if diff := deep.Equal(tags, []string{"HEAD", "INDI @I1@", "FAM @F1@", "_LOOSE", "TRLR"}); diff != nil {
+		t.Errorf("records: %v", diff)
+	}
+
+	report := d.Integrity()
+	if len(report.Dangling) != 1 || report.Dangling[0].Xref != "@I2@" || report.Dangling[0].Line != 7 {
+		t.Errorf("Integrity().Dangling = %v, expected @I2@ at line 7", report.Dangling)
+	}
+}
@@ -14,6 +14,12 @@ import (
 // indent emits spaces based on the level number
 func indent(i int) string {
 	const spaces = "                    "
+	if i < 0 {
+		return ""
+	}
+	if i*2 > len(spaces) {
+		return strings.Repeat("  ", i)
+	}
 	return spaces[:i*2]
 }
 
@@ -107,6 +113,8 @@ func (r *AddressRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -157,6 +165,8 @@ func (r *AlbumRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -344,6 +354,8 @@ func (r *AttributeRecord) String() string {
 	//		ss = append(ss, s)
 	//	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -374,6 +386,8 @@ func (r *AuthorRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -390,6 +404,8 @@ func (r *BibliographyRecord) String() string {
 		ss = append(ss, sas...)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -407,6 +423,8 @@ func (r *BlobRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -433,6 +451,8 @@ func (r *BusinessRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -449,6 +469,8 @@ func (r *CallNumberRecord) String() string {
 		s = fmt.Sprintf("%s%d MEDI %s", indent(r.Level+1), r.Level+1, r.Media)
 		ss = append(ss, s)
 	}
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -470,6 +492,8 @@ func (r *ChangeRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -486,6 +510,8 @@ func (r *CharacterSetRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -500,6 +526,8 @@ func (r *ChildStatusRecord) String() string {
 	s = fmt.Sprintf("%s%d NAME %s", indent(r.Level+1), r.Level+1, r.Name)
 	ss = append(ss, s)
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -618,6 +646,8 @@ func (r *CitationRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -675,6 +705,8 @@ func (r *DataRecord) String() string {
 		ss = append(ss, sas...)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -740,6 +772,8 @@ func (r *DateRecord) String() string {
 		ss = append(ss, sas...)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -786,6 +820,8 @@ func (r EventDefinitionRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -967,6 +1003,8 @@ func (r *EventRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -1017,6 +1055,8 @@ func (r *FamilyLink) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -1156,6 +1196,8 @@ func (r *FamilyRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -1186,6 +1228,8 @@ func (r *FootnoteRecord) String() string {
 		ss = append(ss, sas...)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -1203,6 +1247,8 @@ func (r *GedcomRecord) String() string {
 	s = fmt.Sprintf("%s%d FORM %s", indent(r.Level+1), r.Level+1, r.Form)
 	ss = append(ss, s)
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 
 }
@@ -1325,6 +1371,8 @@ func (r *HeaderRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -1341,6 +1389,8 @@ func (r *HistoryRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -1404,6 +1454,8 @@ func (r *IndividualLink) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -1692,6 +1744,8 @@ func (r *IndividualRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -1718,6 +1772,8 @@ func (r *MediaLink) String() string {
 	s = fmt.Sprintf("%s%d %s %s", indent(r.Level), r.Level, r.Tag, r.Media.Xref)
 	ss = append(ss, s)
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -1933,6 +1989,8 @@ func (r *MediaRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -2050,6 +2108,8 @@ func (r *NameRecord) String() string {
 		}
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -2100,6 +2160,8 @@ func (r *NoteRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -2135,6 +2197,8 @@ func (r *PedigreeRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -2152,6 +2216,8 @@ func (r *PhoneRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -2187,6 +2253,8 @@ func (r *PhotoRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -2204,6 +2272,46 @@ func (r PhotoRecords) String() string {
 	return strings.Join(ss, "\n")
 }
 
+// String stringifies a GEDCOM place definition record
+func (r *PlaceDefinitionRecord) String() string {
+	var ss []string
+	var s string
+
+	xref := ""
+	if r.Xref != "" {
+		xref = fmt.Sprintf("%s ", r.Xref)
+	}
+	s = fmt.Sprintf("%s%d %s_PLAC_DEFN", indent(r.Level), r.Level, xref)
+	ss = append(ss, s)
+
+	if r.Place != "" {
+		s = fmt.Sprintf("%s%d PLAC %s", indent(r.Level+1), r.Level+1, r.Place)
+		ss = append(ss, s)
+	}
+
+	if r.Abbreviation != "" {
+		s = fmt.Sprintf("%s%d ABBR %s", indent(r.Level+1), r.Level+1, r.Abbreviation)
+		ss = append(ss, s)
+	}
+
+	ss = r.UnknownTags.insert(ss)
+
+	return strings.Join(ss, "\n")
+}
+
+// String stringifies a slice of place definition records
+func (r PlaceDefinitionRecords) String() string {
+	var ss []string
+	var s string
+
+	for _, x := range r {
+		s = x.String()
+		ss = append(ss, s)
+	}
+
+	return strings.Join(ss, "\n")
+}
+
 // String stringifies a GEDCOM place part record
 func (r *PlacePartRecord) String() string {
 	var ss []string
@@ -2217,6 +2325,8 @@ func (r *PlacePartRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -2234,7 +2344,7 @@ func (r *PlaceRecord) String() string {
 	ss = append(ss, s)
 
 	if r.Form != "" {
-		s = fmt.Sprintf("%s%d PLAS %s", indent(r.Level+1), r.Level+1, r.Form)
+		s = fmt.Sprintf("%s%d FORM %s", indent(r.Level+1), r.Level+1, r.Form)
 		ss = append(ss, s)
 	}
 
@@ -2270,6 +2380,8 @@ func (r *PlaceRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -2327,6 +2439,8 @@ func (r *PublishRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -2343,6 +2457,64 @@ func (r PublishRecords) String() string {
 	return strings.Join(ss, "\n")
 }
 
+// String stringifies a raw line
+func (r *RawLine) String() string {
+	xref := ""
+	if r.Xref != "" {
+		xref = fmt.Sprintf(" %s", r.Xref)
+	}
+	value := ""
+	if r.Value != "" {
+		value = fmt.Sprintf(" %s", r.Value)
+	}
+	return fmt.Sprintf("%s%d%s %s%s", indent(r.Level), r.Level, xref, r.Tag, value)
+}
+
+// String stringifies a slice of raw lines
+func (r RawLines) String() string {
+	var ss []string
+	var s string
+
+	for _, x := range r {
+		s = x.String()
+		ss = append(ss, s)
+	}
+
+	return strings.Join(ss, "\n")
+}
+
+// insert returns the lines of ss with the lines of r among them, each
+// before the known line which followed it in the input, as Write does
+func (r RawLines) insert(ss []string) []string {
+	if len(r) == 0 {
+		return ss
+	}
+
+	var lines []string
+	var tree RawLines
+	var first string
+	if len(ss) > 0 {
+		first = strings.SplitN(ss[0], "\n", 2)[0]
+	}
+	level, known := r.level([]byte(first)), 0
+	for _, s := range ss {
+		for _, line := range strings.Split(s, "\n") {
+			if lineLevel([]byte(line)) == level {
+				known++
+				for len(r) > 0 && r[0].Position != 0 && r[0].Position <= known {
+					tree, r = r.split()
+					lines = append(lines, tree.String())
+				}
+			}
+			lines = append(lines, line)
+		}
+	}
+	if len(r) > 0 {
+		lines = append(lines, r.String())
+	}
+	return lines
+}
+
 // String stringifies a GEDCOM link to a repository record
 func (r *RepositoryLink) String() string {
 	var ss []string
@@ -2361,6 +2533,8 @@ func (r *RepositoryLink) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -2424,6 +2598,8 @@ func (r *RepositoryRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -2462,6 +2638,8 @@ func (r *RoleRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -2530,6 +2708,11 @@ func (r *RootRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	if len(r.PlaceDefinition_) > 0 { // _PLAC_DEFN
+		s = r.PlaceDefinition_.String()
+		ss = append(ss, s)
+	}
+
 	if len(r.EventDefinition_) > 0 { // _EVENT_DEFN
 		s = r.EventDefinition_.String()
 		ss = append(ss, s)
@@ -2565,6 +2748,8 @@ func (r *RootRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	if r.Trailer != nil { // TRLR
 		s = r.Trailer.String()
 		ss = append(ss, s)
@@ -2584,11 +2769,13 @@ func (r *SchemaRecord) String() string {
 	ss = append(ss, s)
 
 	for _, data := range r.Data {
-		level := int(data[0])
+		level := int(data[0] - '0')
 		s = indent(level) + data
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -2605,6 +2792,8 @@ func (r *ShortTitleRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -2621,6 +2810,8 @@ func (r *SlideShowRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -2769,6 +2960,8 @@ func (r *SourceRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -2792,6 +2985,8 @@ func (r *SubmissionLink) String() string {
 	s = fmt.Sprintf("%s%d SUBN %s", indent(r.Level), r.Level, r.Submission.Xref)
 	ss = append(ss, s)
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -2845,6 +3040,8 @@ func (r *SubmissionRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -2869,6 +3066,8 @@ func (r *SubmitterLink) String() string {
 	s = fmt.Sprintf("%s%d %s %s", indent(r.Level), r.Level, r.Tag, r.Submitter.Xref)
 	ss = append(ss, s)
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -2976,6 +3175,8 @@ func (r *SubmitterRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -3027,6 +3228,8 @@ func (r *SystemRecord) String() string {
 
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -3043,6 +3246,8 @@ func (r *TitleRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -3101,6 +3306,8 @@ func (r *TodoRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -3138,6 +3345,8 @@ func (r *UserReferenceNumberRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
 
@@ -3172,5 +3381,7 @@ func (r *WebTagRecord) String() string {
 		ss = append(ss, s)
 	}
 
+	ss = r.UnknownTags.insert(ss)
+
 	return strings.Join(ss, "\n")
 }
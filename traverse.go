@@ -0,0 +1,161 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"math/big"
+	"strconv"
+)
+
+// TraversalOptions controls Ancestors and Descendants
+type TraversalOptions struct {
+	MaxDepth  int      // generations to go from the individual, or 0 for all
+	Pedigrees []string // PEDI values of FAMC links to follow, or nil for all
+}
+
+// Relative represents an individual found by Ancestors or Descendants
+type Relative struct {
+	Individual *IndividualRecord // the relative
+	Generation int               // 0 for the starting individual, 1 for parents or children, ...
+	Number     string            // Ahnentafel number of an ancestor or d'Aboville number of a descendant
+	Path       IndividualRecords // individuals from the starting individual to the relative
+	ahnentafel *big.Int          // Number of an ancestor
+}
+
+// Traversal iterates over the ancestors or the descendants of an individual.
+//
+//	t := gedcom.Ancestors(indi, gedcom.TraversalOptions{MaxDepth: 4})
+//	for t.Next() {
+//		r := t.Relative()
+//		...
+//	}
+type Traversal struct {
+	opts      TraversalOptions
+	ancestors bool                       // ancestors in breadth first order, or descendants in depth first order
+	pending   []*Relative                // relatives not yet returned by Next
+	current   *Relative                  // relative returned by Relative
+	visited   map[*IndividualRecord]bool // individuals returned or pending
+	Loops     []*Relative                // relatives found on their own path, which were not followed
+	Repeats   []*Relative                // relatives found again through another line, which were not followed
+}
+
+// Ancestors returns a Traversal of the individual and its ancestors, a
+// generation at a time, each in Ahnentafel order: the individual is 1, its
+// father 2 and its mother 3, and the father and mother of n are 2n and 2n+1.
+// An individual with several parent families, such as a birth family and
+// an adoptive one, has all its fathers numbered 2n and all its mothers
+// 2n+1, in the order of its FAMC links. An ancestor found through more
+// than one line is returned once, for the first line, with its lowest
+// number; the other lines are added to Repeats.
+func Ancestors(indi *IndividualRecord, opts TraversalOptions) *Traversal {
+	t := &Traversal{opts: opts, ancestors: true, visited: make(map[*IndividualRecord]bool)}
+	if indi != nil {
+		t.visited[indi] = true
+		t.pending = []*Relative{{
+			Individual: indi,
+			Number:     "1",
+			Path:       IndividualRecords{indi},
+			ahnentafel: big.NewInt(1),
+		}}
+	}
+	return t
+}
+
+// Descendants returns a Traversal of the individual and its descendants in
+// d'Aboville order: the individual is 1, its children 1.1, 1.2, ... and
+// their children 1.1.1, 1.1.2, ... Each descendant's own descendants come
+// before its younger siblings. A descendant found through more than one
+// line is returned once, for the first line; the other lines are added to
+// Repeats.
+func Descendants(indi *IndividualRecord, opts TraversalOptions) *Traversal {
+	t := &Traversal{opts: opts, visited: make(map[*IndividualRecord]bool)}
+	if indi != nil {
+		t.visited[indi] = true
+		t.pending = []*Relative{{
+			Individual: indi,
+			Number:     "1",
+			Path:       IndividualRecords{indi},
+		}}
+	}
+	return t
+}
+
+// Next advances to the next relative, returning false when there are no more
+func (t *Traversal) Next() bool {
+	if len(t.pending) == 0 {
+		t.current = nil
+		return false
+	}
+
+	if t.ancestors {
+		t.current = t.pending[0]
+		t.pending = t.pending[1:]
+	} else {
+		n := len(t.pending) - 1
+		t.current = t.pending[n]
+		t.pending = t.pending[:n]
+	}
+
+	if t.opts.MaxDepth <= 0 || t.current.Generation < t.opts.MaxDepth {
+		t.expand(t.current)
+	}
+	return true
+}
+
+// Relative returns the relative found by the last call to Next
+func (t *Traversal) Relative() *Relative {
+	return t.current
+}
+
+// expand adds the parents or the children of a relative to pending
+func (t *Traversal) expand(r *Relative) {
+	if t.ancestors {
+		two := big.NewInt(2)
+		father := new(big.Int).Mul(r.ahnentafel, two)
+		for _, p := range r.Individual.Fathers(t.opts.Pedigrees...) {
+			t.add(r, p, father.String(), father)
+		}
+		mother := new(big.Int).Add(father, big.NewInt(1))
+		for _, p := range r.Individual.Mothers(t.opts.Pedigrees...) {
+			t.add(r, p, mother.String(), mother)
+		}
+		return
+	}
+
+	// children are pushed in reverse so that the eldest is next
+	children := r.Individual.Children(t.opts.Pedigrees...)
+	for i := len(children) - 1; i >= 0; i-- {
+		t.add(r, children[i], r.Number+"."+strconv.Itoa(i+1), nil)
+	}
+}
+
+// add adds a parent or a child of a relative to pending, or to Loops if it
+// is already on the path to the relative, or to Repeats if it has already
+// been found
+func (t *Traversal) add(r *Relative, indi *IndividualRecord, number string, ahnentafel *big.Int) {
+	path := make(IndividualRecords, len(r.Path), len(r.Path)+1)
+	copy(path, r.Path)
+	next := &Relative{
+		Individual: indi,
+		Generation: r.Generation + 1,
+		Number:     number,
+		Path:       append(path, indi),
+		ahnentafel: ahnentafel,
+	}
+
+	for _, p := range r.Path {
+		if p == indi {
+			t.Loops = append(t.Loops, next)
+			return
+		}
+	}
+	if t.visited[indi] {
+		t.Repeats = append(t.Repeats, next)
+		return
+	}
+	t.visited[indi] = true
+	t.pending = append(t.pending, next)
+}
@@ -0,0 +1,185 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+// In traverseInput @I1@ is the father of @I5@, who is his own grandmother.
+var traverseInput = `0 HEAD
+0 @I1@ INDI
+1 FAMC @F1@
+1 FAMS @F3@
+0 @I2@ INDI
+1 FAMC @F2@
+1 FAMS @F1@
+0 @I3@ INDI
+1 FAMS @F1@
+0 @I4@ INDI
+1 FAMS @F2@
+0 @I5@ INDI
+1 FAMC @F3@
+1 FAMS @F2@
+0 @I6@ INDI
+1 FAMC @F1@
+0 @F1@ FAM
+1 HUSB @I2@
+1 WIFE @I3@
+1 CHIL @I1@
+1 CHIL @I6@
+0 @F2@ FAM
+1 HUSB @I4@
+1 WIFE @I5@
+1 CHIL @I2@
+0 @F3@ FAM
+1 HUSB @I1@
+1 CHIL @I5@
+0 TRLR
+`
+
+// traversal collects the xrefs, generations, numbers and paths of a Traversal
+func traversal(t *Traversal) []string {
+	var ss []string
+	for t.Next() {
+		r := t.Relative()
+		s := r.Individual.Xref + " " + strconv.Itoa(r.Generation) + " " + r.Number + " " + xrefs(r.Path)[0]
+		for _, p := range r.Path[1:] {
+			s += "," + p.Xref
+		}
+		ss = append(ss, s)
+	}
+	return ss
+}
+
+func TestTraversal(t *testing.T) {
+	d := NewDecoder(bytes.NewReader([]byte(traverseInput)))
+	g, err := d.Decode()
+	if err != nil {
+		t.Fatalf("Decode returned error %v", err)
+	}
+	i1, i4 := g.Individual[0], g.Individual[3]
+
+	a := Ancestors(i1, TraversalOptions{})
+	want := []string{
+		"@I1@ 0 1 @I1@",
+		"@I2@ 1 2 @I1@,@I2@",
+		"@I3@ 1 3 @I1@,@I3@",
+		"@I4@ 2 4 @I1@,@I2@,@I4@",
+		"@I5@ 2 5 @I1@,@I2@,@I5@",
+	}
+	if diff := deep.Equal(traversal(a), want); diff != nil {
+		t.Errorf("Ancestors: %v", diff)
+	}
+	if len(a.Loops) != 1 || a.Loops[0].Number != "10" || len(a.Loops[0].Path) != 4 {
+		t.Errorf("Ancestors found loops %v, expected @I1@ as 10", a.Loops)
+	}
+
+	a = Ancestors(i1, TraversalOptions{MaxDepth: 1})
+	if diff := deep.Equal(traversal(a), want[:3]); diff != nil {
+		t.Errorf("Ancestors with MaxDepth 1: %v", diff)
+	}
+
+	dd := Descendants(i4, TraversalOptions{})
+	want = []string{
+		"@I4@ 0 1 @I4@",
+		"@I2@ 1 1.1 @I4@,@I2@",
+		"@I1@ 2 1.1.1 @I4@,@I2@,@I1@",
+		"@I5@ 3 1.1.1.1 @I4@,@I2@,@I1@,@I5@",
+		"@I6@ 2 1.1.2 @I4@,@I2@,@I6@",
+	}
+	if diff := deep.Equal(traversal(dd), want); diff != nil {
+		t.Errorf("Descendants: %v", diff)
+	}
+	if len(dd.Loops) != 1 || dd.Loops[0].Individual != g.Individual[1] {
+		t.Errorf("Descendants found loops %v, expected @I2@", dd.Loops)
+	}
+
+	dd = Descendants(i4, TraversalOptions{MaxDepth: 2, Pedigrees: []string{PedigreeAdopted}})
+	if diff := deep.Equal(traversal(dd), want[:1]); diff != nil {
+		t.Errorf("Descendants adopted: %v", diff)
+	}
+}
+
+// In collapseInput the parents of @I1@ are siblings, and @I1@ was also
+// adopted by @I8@.
+var collapseInput = `0 HEAD
+0 @I1@ INDI
+1 FAMC @F1@
+1 FAMC @F9@
+2 PEDI adopted
+0 @I2@ INDI
+1 FAMC @F2@
+1 FAMS @F1@
+0 @I3@ INDI
+1 FAMC @F2@
+1 FAMS @F1@
+0 @I4@ INDI
+1 FAMS @F2@
+0 @I5@ INDI
+1 FAMS @F2@
+0 @I8@ INDI
+1 FAMS @F9@
+0 @F1@ FAM
+1 HUSB @I2@
+1 WIFE @I3@
+1 CHIL @I1@
+0 @F2@ FAM
+1 HUSB @I4@
+1 WIFE @I5@
+1 CHIL @I2@
+1 CHIL @I3@
+0 @F9@ FAM
+1 HUSB @I8@
+1 CHIL @I1@
+0 TRLR
+`
+
+func TestTraversalCollapse(t *testing.T) {
+	d := NewDecoder(bytes.NewReader([]byte(collapseInput)))
+	g, err := d.Decode()
+	if err != nil {
+		t.Fatalf("Decode returned error %v", err)
+	}
+
+	a := Ancestors(g.Individual[0], TraversalOptions{})
+	want := []string{
+		"@I1@ 0 1 @I1@",
+		"@I2@ 1 2 @I1@,@I2@",
+		"@I8@ 1 2 @I1@,@I8@",
+		"@I3@ 1 3 @I1@,@I3@",
+		"@I4@ 2 4 @I1@,@I2@,@I4@",
+		"@I5@ 2 5 @I1@,@I2@,@I5@",
+	}
+	if diff := deep.Equal(traversal(a), want); diff != nil {
+		t.Errorf("Ancestors: %v", diff)
+	}
+	var repeats []string
+	for _, r := range a.Repeats {
+		repeats = append(repeats, r.Individual.Xref+" "+r.Number)
+	}
+	if diff := deep.Equal(repeats, []string{"@I4@ 6", "@I5@ 7"}); diff != nil {
+		t.Errorf("Ancestors repeats: %v", diff)
+	}
+
+	dd := Descendants(g.Individual[3], TraversalOptions{})
+	want = []string{
+		"@I4@ 0 1 @I4@",
+		"@I2@ 1 1.1 @I4@,@I2@",
+		"@I1@ 2 1.1.1 @I4@,@I2@,@I1@",
+		"@I3@ 1 1.2 @I4@,@I3@",
+	}
+	if diff := deep.Equal(traversal(dd), want); diff != nil {
+		t.Errorf("Descendants: %v", diff)
+	}
+	if len(dd.Repeats) != 1 || dd.Repeats[0].Number != "1.2.1" {
+		t.Errorf("Descendants found repeats %v, expected @I1@ as 1.2.1", dd.Repeats)
+	}
+}
@@ -0,0 +1,157 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"bytes"
+	"io"
+	"strings"
+)
+
+// utf8BOM starts GEDCOM written for conversion, so that HEAD.CHAR is not
+// used to read what is already UTF-8
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// Node represents a GEDCOM line and its subordinate lines, whatever their
+// tags
+type Node struct {
+	Level    int     // level of the line, or -1 for the root of a tree
+	Xref     string  // xref_id of the line, with its @s
+	Tag      string  // tag of the line
+	Value    string  // value of the line, with @@ in text as @
+	Children []*Node // subordinate lines, including CONT and CONC
+	Line     int     // line number in the input, starting at 1, or 0
+}
+
+// ReadTree reads GEDCOM lines into a tree of Nodes. The root has Level -1
+// and the level 0 records as its Children. A line more than one level
+// deeper than the line before is placed under that line. The character
+// set is found as by Decoder.
+func ReadTree(r io.Reader) (*Node, error) {
+	d := &Decoder{}
+	lr := newLineReader(d.charsetReader(r))
+	s := &lr.s
+
+	root := &Node{Level: -1}
+	stack := []*Node{root}
+	for {
+		serr, err := lr.next()
+		switch {
+		case err == io.EOF:
+			return root, nil
+		case err != nil:
+			return root, &DecodeError{Line: lr.lineNum + 1, Kind: ErrRead, Severity: SeverityFatal, Msg: "read failed", Err: err}
+		case serr != nil:
+			return root, &DecodeError{Line: lr.lineNum, Offset: lr.offset, Level: s.level, Kind: ErrSyntax, Severity: SeverityFatal, Msg: "malformed line", Err: serr}
+		}
+
+		n := &Node{Level: s.level, Tag: lr.tag(), Value: lr.value(), Line: lr.lineNum}
+		if len(s.ref) > 0 {
+			n.Xref = string(s.ref)
+		}
+
+		// stack[i] is the last node at level i-1
+		if s.level+1 < len(stack) {
+			stack = stack[:s.level+1]
+		}
+		parent := stack[len(stack)-1]
+		parent.Children = append(parent.Children, n)
+		stack = append(stack, n)
+	}
+}
+
+// Find returns the first node at a path of tags below the node, like
+// BIRT.DATE, or nil
+func (n *Node) Find(path string) *Node {
+	if nodes := n.FindAll(path); len(nodes) > 0 {
+		return nodes[0]
+	}
+	return nil
+}
+
+// FindAll returns the nodes at a path of tags below the node, in order;
+// tags are matched ignoring case
+func (n *Node) FindAll(path string) []*Node {
+	nodes := []*Node{n}
+	if path == "" {
+		return nodes
+	}
+	for _, tag := range strings.Split(path, ".") {
+		var next []*Node
+		for _, node := range nodes {
+			for _, child := range node.Children {
+				if strings.EqualFold(child.Tag, tag) {
+					next = append(next, child)
+				}
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// Text returns the value of the node joined with its CONT and CONC lines
+func (n *Node) Text() string {
+	var b strings.Builder
+	b.WriteString(n.Value)
+	for _, child := range n.Children {
+		switch child.Tag {
+		case "CONT":
+			b.WriteString("\n" + child.Value)
+		case "CONC":
+			b.WriteString(child.Value)
+		}
+	}
+	return b.String()
+}
+
+// Write writes the node and its subordinate lines as GEDCOM
+func (n *Node) Write(w io.Writer) (nbytes int, err error) {
+	var m int
+
+	if n.Level >= 0 {
+		m, err = WriteLine0(w, n.Level, n.Xref, n.Tag, n.Value)
+		nbytes += m
+		if err != nil {
+			return nbytes, err
+		}
+	}
+
+	for _, child := range n.Children {
+		m, err = child.Write(w)
+		nbytes += m
+		if err != nil {
+			return nbytes, err
+		}
+	}
+	return nbytes, err
+}
+
+// String returns the node and its subordinate lines as GEDCOM
+func (n *Node) String() string {
+	var b strings.Builder
+	n.Write(&b)
+	return b.String()
+}
+
+// RootRecord decodes the node and its subordinate lines, which are
+// usually a tree from ReadTree or Tree
+func (n *Node) RootRecord() (*RootRecord, error) {
+	buf := bytes.NewBuffer(append([]byte(nil), utf8BOM...))
+	if _, err := n.Write(buf); err != nil {
+		return nil, err
+	}
+	return NewDecoder(buf).Decode()
+}
+
+// Tree returns the lines the RootRecord is written as, as a tree of Nodes
+func (r *RootRecord) Tree() (*Node, error) {
+	buf := bytes.NewBuffer(append([]byte(nil), utf8BOM...))
+	if _, err := r.Write(buf); err != nil {
+		return nil, err
+	}
+	return ReadTree(buf)
+}
@@ -0,0 +1,123 @@
+/*
+This is free and unencumbered software released into the public domain. For more
+information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
+*/
+
+package gedcom
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+var treeInput = `0 HEAD
+1 CHAR UTF-8
+0 @I1@ INDI
+1 NAME John /Smith/
+1 BIRT
+2 DATE 1 JAN 1850
+2 _CUSTOM extension
+3 _MORE deeper
+1 BIRT
+2 DATE 1852
+0 @N1@ NOTE First l
+1 CONC ine
+1 CONT Second line
+0 TRLR
+`
+
+func TestReadTree(t *testing.T) {
+	root, err := ReadTree(strings.NewReader(treeInput))
+	if err != nil {
+		t.Fatalf("ReadTree returned error %v", err)
+	}
+
+	var tags []string
+	for _, n := range root.Children {
+		tags = append(tags, n.Tag)
+	}
+	if diff := deep.Equal(tags, []string{"HEAD", "INDI", "NOTE", "TRLR"}); diff != nil {
+		t.Errorf("level 0 tags: %v", diff)
+	}
+
+	indi := root.Find("INDI")
+	want := &Node{Level: 2, Tag: "_CUSTOM", Value: "extension", Line: 7, Children: []*Node{
+		{Level: 3, Tag: "_MORE", Value: "deeper", Line: 8},
+	}}
+	if diff := deep.Equal(indi.Find("BIRT._CUSTOM"), want); diff != nil {
+		t.Errorf("Find(BIRT._CUSTOM): %v", diff)
+	}
+	if indi.Xref != "@I1@" || indi.Level != 0 || indi.Line != 3 {
+		t.Errorf("INDI = %d %s line %d", indi.Level, indi.Xref, indi.Line)
+	}
+
+	var dates []string
+	for _, n := range indi.FindAll("birt.date") {
+		dates = append(dates, n.Value)
+	}
+	if diff := deep.Equal(dates, []string{"1 JAN 1850", "1852"}); diff != nil {
+		t.Errorf("FindAll(birt.date): %v", diff)
+	}
+	if n := indi.Find("DEAT.DATE"); n != nil {
+		t.Errorf("Find(DEAT.DATE) = %v, expected nil", n)
+	}
+	if n := root.Find("INDI.BIRT._CUSTOM._MORE"); n == nil || n.Value != "deeper" {
+		t.Errorf("Find(INDI.BIRT._CUSTOM._MORE) = %v", n)
+	}
+
+	if s := root.Find("NOTE").Text(); s != "First line\nSecond line" {
+		t.Errorf("Text() = %q", s)
+	}
+}
+
+func TestReadTreeError(t *testing.T) {
+	_, err := ReadTree(strings.NewReader("0 HEAD\nX bad\n0 TRLR\n"))
+	de, ok := err.(*DecodeError)
+	if !ok || de.Line != 2 || de.Kind != ErrSyntax {
+		t.Errorf("ReadTree returned %v, expected a syntax error at line 2", err)
+	}
+}
+
+func TestTreeRoundTrip(t *testing.T) {
+	root, err := ReadTree(strings.NewReader(treeInput))
+	if err != nil {
+		t.Fatalf("ReadTree returned error %v", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := root.Write(&buf); err != nil {
+		t.Fatalf("Write returned error %v", err)
+	}
+	again, err := ReadTree(&buf)
+	if err != nil {
+		t.Fatalf("ReadTree of Write returned error %v", err)
+	}
+	if again.String() != root.String() {
+		t.Errorf("Write and ReadTree changed the tree:\n%s\nexpected:\n%s", again, root)
+	}
+
+	g, err := root.RootRecord()
+	if err != nil {
+		t.Fatalf("RootRecord returned error %v", err)
+	}
+	if len(g.Individual) != 1 || len(g.Individual[0].Event) != 2 {
+		t.Fatalf("RootRecord decoded %d individuals", len(g.Individual))
+	}
+	if s := g.Individual[0].Event[1].Date.Date; s != "1852" {
+		t.Errorf("second BIRT.DATE = %q, expected 1852", s)
+	}
+
+	tree, err := g.Tree()
+	if err != nil {
+		t.Fatalf("Tree returned error %v", err)
+	}
+	if n := tree.Find("INDI.BIRT._CUSTOM._MORE"); n == nil || n.Value != "deeper" {
+		t.Errorf("Tree lost INDI.BIRT._CUSTOM._MORE: %v", n)
+	}
+	if s := tree.Find("NOTE").Text(); s != "First line\nSecond line" {
+		t.Errorf("Tree NOTE Text() = %q", s)
+	}
+}
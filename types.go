@@ -5,22 +5,21 @@ information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
 
 package gedcom
 
-import "log"
-
 // AddressRecord represents an address record
 type AddressRecord struct {
-	Level      int          // ..ADDR level
-	Full       string       // ..ADDR value
-	Line1      string       // ..ADDR.ADR1
-	Line2      string       // ..ADDR.ADR2
-	Line3      string       // ..ADDR.ADR3
-	City       string       // ..ADDR.CITY
-	State      string       // ..ADDR.STAE
-	PostalCode string       // ..ADDR.POST
-	Country    string       // ..ADDR.CTRY
-	Phone      PhoneRecords // ..ADDR.PHON
-	Name_      string       // ..ADDR._NAME (AQ14)
-	Note       NoteRecords  // .. ADDR.NOTE (Leg8)
+	Level       int          // ..ADDR level
+	Full        string       // ..ADDR value
+	Line1       string       // ..ADDR.ADR1
+	Line2       string       // ..ADDR.ADR2
+	Line3       string       // ..ADDR.ADR3
+	City        string       // ..ADDR.CITY
+	State       string       // ..ADDR.STAE
+	PostalCode  string       // ..ADDR.POST
+	Country     string       // ..ADDR.CTRY
+	Phone       PhoneRecords // ..ADDR.PHON
+	Name_       string       // ..ADDR._NAME (AQ14)
+	Note        NoteRecords  // .. ADDR.NOTE (Leg8)
+	UnknownTags RawLines     // unhandled lines
 }
 
 // AddressRecords represents a slice of address records
@@ -28,12 +27,13 @@ type AddressRecords []*AddressRecord
 
 // AlbumRecord represents a GEDCOM album record (MH/FTB8)
 type AlbumRecord struct { // MH/FTB8
-	Level  int          // ..ALBUM level
-	Xref   string       // xref_id of level 0 ..ALBUM
-	Rin    []string     // ALBUM.RIN
-	Title  string       // ALBUM.TITL
-	Desc_  string       // ALBUM._DESC (MH-FTB8)
-	Photo_ PhotoRecords // ALBUM._PHOTO
+	Level       int          // ..ALBUM level
+	Xref        string       // xref_id of level 0 ..ALBUM
+	Rin         []string     // ALBUM.RIN
+	Title       string       // ALBUM.TITL
+	Desc_       string       // ALBUM._DESC (MH-FTB8)
+	Photo_      PhotoRecords // ALBUM._PHOTO
+	UnknownTags RawLines     // unhandled lines
 }
 
 // AlbumRecords represents a slice of album records (MH/FTB8)
@@ -78,6 +78,7 @@ type AttributeRecord struct {
 	UpdateTime_     string          // ..EVEN._UPD
 	AlternateBirth_ string          // ..EVEN._ALT_BIRTH (AQ14)
 	Confidential_   string          // ..EVEN._CONFIDENTIAL (AQ14)
+	UnknownTags     RawLines        // unhandled lines
 }
 
 // VitalAttribute returns true when an attribute is a vital attribute
@@ -100,22 +101,25 @@ type AttributeRecords []*AttributeRecord
 
 // AuthorRecord represents a data record
 type AuthorRecord struct {
-	Level        int    // ..AUTH level
-	Author       string // value of ..AUTH
-	Abbreviation string // ..AUTH.ABBR
+	Level        int      // ..AUTH level
+	Author       string   // value of ..AUTH
+	Abbreviation string   // ..AUTH.ABBR
+	UnknownTags  RawLines // unhandled lines
 }
 
 // BibliographyRecord represents a bibliography record
 type BibliographyRecord struct {
-	Level     int    // ..BIBL level
-	Value     string // ..BIBL value
-	Component string // ..BIBL.COMP
+	Level       int      // ..BIBL level
+	Value       string   // ..BIBL value
+	Component   string   // ..BIBL.COMP
+	UnknownTags RawLines // unhandled lines
 }
 
 // BlobRecord represents a binary large object record
 type BlobRecord struct {
-	Level int    // ..BLOB level
-	Data  string // ..BLOB.CONT
+	Level       int      // ..BLOB level
+	Data        string   // ..BLOB.CONT
+	UnknownTags RawLines // unhandled lines
 }
 
 // BusinessRecord represents a business record
@@ -125,34 +129,39 @@ type BusinessRecord struct {
 	Address      *AddressRecord // ..HEAD.SOUR.CORP.ADDR
 	Phone        PhoneRecords   // ..HEAD.SOUR.CORP.PHON
 	WebSite      string         // ..HEAD.SOUR.CORP.WWW
+	UnknownTags  RawLines       // unhandled lines
 }
 
 // CallNumberRecord represents a call number record
 type CallNumberRecord struct {
-	Level      int    // ..REPO.CALN level
-	CallNumber string // ..REPO.CALN value
-	Media      string // ..REPO.CALN.MEDI
+	Level       int      // ..REPO.CALN level
+	CallNumber  string   // ..REPO.CALN value
+	Media       string   // ..REPO.CALN.MEDI
+	UnknownTags RawLines // unhandled lines
 }
 
 // ChangeRecord represents a change record
 type ChangeRecord struct {
-	Level int         // ..CHAN level
-	Date  *DateRecord // ..CHAN.DATE
-	Note  NoteRecords // ..CHAN.NOTE
+	Level       int         // ..CHAN level
+	Date        *DateRecord // ..CHAN.DATE
+	Note        NoteRecords // ..CHAN.NOTE
+	UnknownTags RawLines    // unhandled lines
 }
 
 // CharacterSetRecord represents a character set record
 type CharacterSetRecord struct {
-	Level        int    // ..CHAR level
-	CharacterSet string // ..CHAR value
-	Version      string // ..CHAR.VERS
+	Level        int      // ..CHAR level
+	CharacterSet string   // ..CHAR value
+	Version      string   // ..CHAR.VERS
+	UnknownTags  RawLines // unhandled lines
 }
 
 // ChildStatusRecord represents a child status record
 type ChildStatusRecord struct {
-	Level int    // CSTA level; always 0
-	Xref  string // xref_id of 0 level CSTA
-	Name  string // CSTA.NAME
+	Level       int      // CSTA level; always 0
+	Xref        string   // xref_id of 0 level CSTA
+	Name        string   // CSTA.NAME
+	UnknownTags RawLines // unhandled lines
 }
 
 // ChildStatusRecords represents a slice of child status records
@@ -180,31 +189,40 @@ type CitationRecord struct {
 	ReferenceNumber   string       // ..SOUR.REFN
 	Rin_              string       // ..SOUR._RIN (AQ14)
 	AppliesTo_        string       // ..SOUR._APPLIES_TO (AQ15)
+	UnknownTags       RawLines     // unhandled lines
 
 	source *SourceRecord // linked source
 }
 
-// Source retrieves the SourceRecord linked by the citation
+// Source retrieves the SourceRecord linked by the citation, or nil for a
+// citation without a pointer. The Decoder, which may be nil, is only used
+// if the link has not been resolved.
 func (r *CitationRecord) Source(d *Decoder) *SourceRecord {
-	if r.source == nil {
+	if r.source == nil && d != nil {
 		r.source = d.FindSource(r.Value)
 	}
 	return r.source
 }
 
+// SetSource stores the SourceRecord linked by the citation
+func (r *CitationRecord) SetSource(s *SourceRecord) {
+	r.source = s
+}
+
 // CitationRecords represents a slice of citation records
 type CitationRecords []*CitationRecord
 
 // DataRecord represents a data record
 type DataRecord struct {
-	Level     int          // ..DATA level
-	Data      string       // value of ..DATA
-	Date      string       // ..DATA.DATE
-	Copyright string       // ..DATA.COPR
-	Text      string       // ..DATA.TEXT
-	Event     EventRecords // ..DATA.EVEN
-	Agency    string       // ..DATA.AGNC
-	Note      NoteRecords  // ..DATA.NOTE
+	Level       int          // ..DATA level
+	Data        string       // value of ..DATA
+	Date        string       // ..DATA.DATE
+	Copyright   string       // ..DATA.COPR
+	Text        string       // ..DATA.TEXT
+	Event       EventRecords // ..DATA.EVEN
+	Agency      string       // ..DATA.AGNC
+	Note        NoteRecords  // ..DATA.NOTE
+	UnknownTags RawLines     // unhandled lines
 }
 
 // DataRecords represents a slice of data records
@@ -212,17 +230,18 @@ type DataRecords []*DataRecord
 
 // DateRecord represents a date
 type DateRecord struct {
-	Level     int    // ..DATE level
-	Tag       string // ..DATE tag
-	Date      string // ..DATE value
-	Time      string // ..DATE.TIME value
-	Text      string // ..DATE.TEXT
-	Day       string // ..DATE.DATD
-	Month     string // ..DATE.DATM
-	Year      string // ..DATE.DATY
-	Full      string // ..DATE.DATF
-	Short     string // ..DATE.DATS
-	TimeZone_ string // .. DATE._TIMEZONE (MH/FTB8)
+	Level       int      // ..DATE level
+	Tag         string   // ..DATE tag
+	Date        string   // ..DATE value
+	Time        string   // ..DATE.TIME value
+	Text        string   // ..DATE.TEXT
+	Day         string   // ..DATE.DATD
+	Month       string   // ..DATE.DATM
+	Year        string   // ..DATE.DATY
+	Full        string   // ..DATE.DATF
+	Short       string   // ..DATE.DATS
+	TimeZone_   string   // .. DATE._TIMEZONE (MH/FTB8)
+	UnknownTags RawLines // unhandled lines
 }
 
 // EventDefinitionRecord represents a GEDCOM event definition record.
@@ -238,6 +257,7 @@ type EventDefinitionRecord struct {
 	DescriptionFlag_ string       // _EVENT_DEFN._DESC_FLAG
 	Association_     string       // _EVENT_DEFN._Assoc
 	RecordInternal_  string       // _EVENT_DEFN._RIN
+	UnknownTags      RawLines     // unhandled lines
 }
 
 // EventDefinitionRecords represents a slice of event definition records.
@@ -281,6 +301,7 @@ type EventRecord struct {
 	UpdateTime_     string          // ..EVEN._UPD
 	AlternateBirth_ string          // ..EVEN._ALT_BIRTH (AQ14)
 	Confidential_   string          // ..EVEN._CONFIDENTIAL (AQ14)
+	UnknownTags     RawLines        // unhandled lines
 }
 
 // VitalEvent returns true when an event is a vital event or attribute
@@ -303,26 +324,31 @@ type EventRecords []*EventRecord
 
 // FamilyLink represents a GEDCOM link to a family record.
 type FamilyLink struct {
-	Level    int             //  level
-	Tag      string          // tag from INDI.FAMC or INDI.FAMS or EVEN.FAMC
-	Value    string          // value of FAMC, FAMS, etc.
-	Adopted  string          // INDI.FAMC.ADOP or ...
-	Primary_ string          // INDI.FAMC._PRIMARY or ...
-	Note     NoteRecords     // INDI.FAMC.NOTE or ..
-	Pedigree *PedigreeRecord // INDI.FAMC.PEDI or ..
-	Citation CitationRecords // INDI.FAMC.SOUR or ..
+	Level       int             //  level
+	Tag         string          // tag from INDI.FAMC or INDI.FAMS or EVEN.FAMC
+	Value       string          // value of FAMC, FAMS, etc.
+	Adopted     string          // INDI.FAMC.ADOP or ...
+	Primary_    string          // INDI.FAMC._PRIMARY or ...
+	Note        NoteRecords     // INDI.FAMC.NOTE or ..
+	Pedigree    *PedigreeRecord // INDI.FAMC.PEDI or ..
+	Citation    CitationRecords // INDI.FAMC.SOUR or ..
+	UnknownTags RawLines        // unhandled lines
 
 	family *FamilyRecord // target of INDI.FAMC or INDI.FAMS or EVEN.FAMC
 }
 
-// GetFamily retrieves the FamilyRecord linked by the FamilyLink
+// Family returns the FamilyRecord linked by the FamilyLink, or nil if the
+// link has not been resolved
+func (r *FamilyLink) Family() *FamilyRecord {
+	return r.family
+}
+
+// GetFamily retrieves the FamilyRecord linked by the FamilyLink. The
+// Decoder, which may be nil, is only used if the link has not been resolved.
 func (r *FamilyLink) GetFamily(d *Decoder) *FamilyRecord {
 	if r.family == nil && d != nil {
 		r.family = d.FindFamily(r.Value)
 	}
-	if r.family == nil {
-		log.Printf("Warning: GetFamily returns nil for '%s'\n", r.Value)
-	}
 	return r.family
 }
 
@@ -355,6 +381,7 @@ type FamilyRecord struct {
 	Submitter           SubmitterLinks             // FAM.SUBM
 	Change              *ChangeRecord              // FAM.CHAN
 	UpdateTime_         string                     // FAM._UPD
+	UnknownTags         RawLines                   // unhandled lines
 }
 
 // FamilyRecords represents a slice of family records.
@@ -362,16 +389,18 @@ type FamilyRecords []*FamilyRecord
 
 // FootnoteRecord represents a footnote record
 type FootnoteRecord struct {
-	Level     int    // ..FOOT level
-	Value     string // ..FOOT value
-	Component string // ..FOOT.COMP
+	Level       int      // ..FOOT level
+	Value       string   // ..FOOT value
+	Component   string   // ..FOOT.COMP
+	UnknownTags RawLines // unhandled lines
 }
 
 // GedcomRecord represents a gedcom record
 type GedcomRecord struct {
-	Level   int    // HEAD.GEDC level
-	Version string // HEAD.GEDC.VERS
-	Form    string // HEAD.GEDC.FORM
+	Level       int      // HEAD.GEDC level
+	Version     string   // HEAD.GEDC.VERS
+	Form        string   // HEAD.GEDC.FORM
+	UnknownTags RawLines // unhandled lines
 }
 
 // HeaderRecord represents a GEDCOM header record
@@ -401,13 +430,15 @@ type HeaderRecord struct {
 	Submitter           SubmitterLinks      // HEAD.SUBM
 	Submission          SubmissionLinks     // HEAD.SUBN
 	Schema              *SchemaRecord       // HEAD.SCHEMA
+	UnknownTags         RawLines            // unhandled lines
 }
 
 // HistoryRecord represents a history record
 type HistoryRecord struct {
-	Level    int             // ..HIST level
-	History  string          // ..HIST value
-	Citation CitationRecords // ..HIST.SOUR
+	Level       int             // ..HIST level
+	History     string          // ..HIST value
+	Citation    CitationRecords // ..HIST.SOUR
+	UnknownTags RawLines        // unhandled lines
 }
 
 // HistoryRecords represents a slice of history records
@@ -424,6 +455,7 @@ type IndividualLink struct {
 	Note         NoteRecords       // FAM.HUSB.NOTE or FAM.WIFE.NOTE or FAM.CHILD.NOTE
 	Age          string            // ..EVEN.HUSB.AGE or ..EVEN.WIFE.AGE or ..EVEN.SPOU.AGE
 	Preferred_   string            // FAM.HUSB._PREF or FAM.WIFE._PREF or ... (Leg8)
+	UnknownTags  RawLines          // unhandled lines
 }
 
 // IndividualLinks represents a slice of links to individual records
@@ -477,6 +509,7 @@ type IndividualRecord struct {
 	Change              *ChangeRecord              // INDI.CHAN
 	Todo_               []string                   // INDI._TODO (AQ15)
 	Anecdote            []string                   // INDI.Anecdote (Custom - MH/FTB8)
+	UnknownTags         RawLines                   // unhandled lines
 }
 
 // IndividualRecords represents a slice of individual records
@@ -484,10 +517,11 @@ type IndividualRecords []*IndividualRecord
 
 // MediaLink represents a link to an media record
 type MediaLink struct {
-	Level int          // ..OBJE level
-	Tag   string       // tag from OBJE or _PROF
-	Value string       // value from OBJE or _PROF
-	Media *MediaRecord // target of OBJE or _PROF
+	Level       int          // ..OBJE level
+	Tag         string       // tag from OBJE or _PROF
+	Value       string       // value from OBJE or _PROF
+	Media       *MediaRecord // target of OBJE or _PROF
+	UnknownTags RawLines     // unhandled lines
 }
 
 // MediaLinks represents a slice of links to media records
@@ -534,6 +568,7 @@ type MediaRecord struct {
 	SrcPp_              string                     // OBJE._SRCPP (AQ15)
 	SrcFlip_            string                     // OBJE._SRCFLIP (AQ15)
 	FsFtId_             string                     // OBJE._FSFTID (AQ15)
+	UnknownTags         RawLines                   // unhandled lines
 
 	mediaLinks MediaLinks // OBJE.OBJE (AQ15)
 }
@@ -562,6 +597,7 @@ type NameRecord struct {
 	Nickname           []string        // ..NAME.NICK
 	Citation           CitationRecords // ..NAME.SOUR
 	Note               NoteRecords     // ..NAME.NOTE
+	UnknownTags        RawLines        // unhandled lines
 }
 
 // NameRecords represents a slice of name records
@@ -577,6 +613,20 @@ type NoteRecord struct {
 	RecordInternal      string                     // ..NOTE.RecordInternal
 	Change              *ChangeRecord              // ..NOTE.CHAN
 	Description_        string                     // ..NOTE._DESCRIPTION (MH/FTB8)
+	UnknownTags         RawLines                   // unhandled lines
+
+	target *NoteRecord // level 0 NOTE linked by a pointer value
+}
+
+// Target returns the level 0 NoteRecord linked by a NOTE with a pointer
+// value, or nil for a note with text or a link which has not been resolved
+func (r *NoteRecord) Target() *NoteRecord {
+	return r.target
+}
+
+// SetTarget stores the level 0 NoteRecord linked by a NOTE with a pointer value
+func (r *NoteRecord) SetTarget(n *NoteRecord) {
+	r.target = n
 }
 
 // NoteRecords represents a slice of note records
@@ -584,17 +634,19 @@ type NoteRecords []*NoteRecord
 
 // PedigreeRecord represents a GEDCOM pedigree record.
 type PedigreeRecord struct {
-	Level    int    // ..FAMC.PEDI level
-	Pedigree string // ..FAMC.PEDI value
-	Husband_ string // ..FAMC.PEDI._HUSB value
-	Wife_    string // ..FAMC.PEDI._WIFE value
+	Level       int      // ..FAMC.PEDI level
+	Pedigree    string   // ..FAMC.PEDI value
+	Husband_    string   // ..FAMC.PEDI._HUSB value
+	Wife_       string   // ..FAMC.PEDI._WIFE value
+	UnknownTags RawLines // unhandled lines
 }
 
 // PhoneRecord represents a GEDCOM phone record.
 type PhoneRecord struct {
-	Level int    // ..PHON level
-	Phone string // ..PHON value
-	Type_ string // ..PHON._TYPE value (MH/FTB8)
+	Level       int      // ..PHON level
+	Phone       string   // ..PHON value
+	Type_       string   // ..PHON._TYPE value (MH/FTB8)
+	UnknownTags RawLines // unhandled lines
 }
 
 // PhoneRecords represents a slice of phone records
@@ -602,9 +654,10 @@ type PhoneRecords []*PhoneRecord
 
 // PhotoRecord represents a GEDCOM photo record.
 type PhotoRecord struct { // (MH/FTB8)
-	Level int    // .._PHOTO level
-	Uid_  string // .._PHOTO._UID (MH/FTB8)
-	Prin_ string // .._PHOTO._PRIN (MH/FTB8)
+	Level       int      // .._PHOTO level
+	Uid_        string   // .._PHOTO._UID (MH/FTB8)
+	Prin_       string   // .._PHOTO._PRIN (MH/FTB8)
+	UnknownTags RawLines // unhandled lines
 }
 
 // PhotoRecords represents a slice of phone records.
@@ -612,10 +665,11 @@ type PhotoRecords []*PhotoRecord // (MH/FTB8)
 
 // PlaceDefinitionRecord represents a GEDCOM place definition record.
 type PlaceDefinitionRecord struct {
-	Level        int    // Level 0 _PLAC_DEFN
-	Xref         string // Level 0 xref
-	Place        string // _PLAC_DEFN.PLAC
-	Abbreviation string // _PLAC_DEFN.ABBR
+	Level        int      // Level 0 _PLAC_DEFN
+	Xref         string   // Level 0 xref
+	Place        string   // _PLAC_DEFN.PLAC
+	Abbreviation string   // _PLAC_DEFN.ABBR
+	UnknownTags  RawLines // unhandled lines
 }
 
 // PlaceDefinitionRecords represents a slice of place definition records.
@@ -623,10 +677,11 @@ type PlaceDefinitionRecords []*PlaceDefinitionRecord
 
 // PlacePartRecord represents a place part record
 type PlacePartRecord struct {
-	Level        int    // ..PLAC.PLAn level, n=0..4
-	Tag          string // ..PLAC.PLAn tag
-	Part         string // ..PLAC.PLAn value
-	Jurisdiction string // ..PLAC.PLAn.JURI
+	Level        int      // ..PLAC.PLAn level, n=0..4
+	Tag          string   // ..PLAC.PLAn tag
+	Part         string   // ..PLAC.PLAn value
+	Jurisdiction string   // ..PLAC.PLAn.JURI
+	UnknownTags  RawLines // unhandled lines
 }
 
 // PlacePartRecords represents a slice of place part records
@@ -634,17 +689,18 @@ type PlacePartRecords []*PlacePartRecord
 
 // PlaceRecord represents a GEDCOM place record
 type PlaceRecord struct {
-	Level     int              // ..PLAC level; 0 or higher
-	Xref      string           // xref_id of 0 level PLAC
-	Tag       string           // ..PLAC tag
-	Name      string           // ..PLAC value
-	Form      string           // ..PLAC.FORM
-	ShortName string           // ..PLAC.PLAS
-	Modifier  string           // ..PLAC.PLAM
-	Parts     PlacePartRecords // ..PLAC.PLAn n=0..4
-	Citation  CitationRecords  // ..PLAC.SOUR
-	Note      NoteRecords      // ..PLAC.NOTE
-	Change    *ChangeRecord    // ..PLAC.CHAN
+	Level       int              // ..PLAC level; 0 or higher
+	Xref        string           // xref_id of 0 level PLAC
+	Tag         string           // ..PLAC tag
+	Name        string           // ..PLAC value
+	Form        string           // ..PLAC.FORM
+	ShortName   string           // ..PLAC.PLAS
+	Modifier    string           // ..PLAC.PLAM
+	Parts       PlacePartRecords // ..PLAC.PLAn n=0..4
+	Citation    CitationRecords  // ..PLAC.SOUR
+	Note        NoteRecords      // ..PLAC.NOTE
+	Change      *ChangeRecord    // ..PLAC.CHAN
+	UnknownTags RawLines         // unhandled lines
 }
 
 // PlaceRecords represents a slice of place records
@@ -652,25 +708,39 @@ type PlaceRecords []*PlaceRecord
 
 // PublishRecord represents a GEDCOM publish record (MH/FTB8)
 type PublishRecord struct {
-	Level        int    // _PUBLISH level; always 0
-	Xref         string // xref_id of 0 level _PUBLISH; always blank
-	SiteAddress_ string // _PUBLISH._SITEADDRESS (MH/FTB8)
-	SiteName_    string // _PUBLISH._SITENAME (MH/FTB8)
-	SiteId_      string // _PUBLISH._SITEID (MH/FTB8)
-	UserName_    string // _PUBLISH._USERNAME (MH/FTB8)
-	Disabled_    string // _PUBLISH._DISABLED (MH/FTB8)
+	Level        int      // _PUBLISH level; always 0
+	Xref         string   // xref_id of 0 level _PUBLISH; always blank
+	SiteAddress_ string   // _PUBLISH._SITEADDRESS (MH/FTB8)
+	SiteName_    string   // _PUBLISH._SITENAME (MH/FTB8)
+	SiteId_      string   // _PUBLISH._SITEID (MH/FTB8)
+	UserName_    string   // _PUBLISH._USERNAME (MH/FTB8)
+	Disabled_    string   // _PUBLISH._DISABLED (MH/FTB8)
+	UnknownTags  RawLines // unhandled lines
 }
 
 // PublishRecords represents a slice of publish records
 type PublishRecords []*PublishRecord
 
+// RawLine represents a line which is not understood by the decoder
+type RawLine struct {
+	Level    int    // line level
+	Xref     string // line xref_id
+	Tag      string // line tag
+	Value    string // line value
+	Position int    // one more than the known lines before it among its siblings, or its parent's under a text value, or 0 for after them
+}
+
+// RawLines represents a slice of raw lines, in input order
+type RawLines []*RawLine
+
 // RepositoryLink represents a link to a repository record
 type RepositoryLink struct {
-	Level      int               // ..REPO level
-	Xref       string            // xref_id of 0 level REPO
-	Repository *RepositoryRecord // The linked repository
-	CallNumber *CallNumberRecord // ..REPO.CALN
-	Note       NoteRecords       // ..REPO.NOTE
+	Level       int               // ..REPO level
+	Xref        string            // xref_id of 0 level REPO
+	Repository  *RepositoryRecord // The linked repository
+	CallNumber  *CallNumberRecord // ..REPO.CALN
+	Note        NoteRecords       // ..REPO.NOTE
+	UnknownTags RawLines          // unhandled lines
 }
 
 // RepositoryLinks represents a slice of links to repository records
@@ -688,6 +758,7 @@ type RepositoryRecord struct {
 	RecordInternal      string                     // REPO.RecordInternal
 	Note                NoteRecords                // REPO.NOTE
 	Change              *ChangeRecord              // REPO.CHAN
+	UnknownTags         RawLines                   // unhandled lines
 }
 
 // RepositoryRecords represents a slice of repository records
@@ -695,10 +766,11 @@ type RepositoryRecords []*RepositoryRecord
 
 // RoleRecord represents a role record
 type RoleRecord struct {
-	Level      int               // ..ROLE level
-	Role       string            // ..ROLE no-ref value
-	Individual *IndividualRecord // ..ROLE ref value
-	Principal  string            // ..ROLE.PRIN
+	Level       int               // ..ROLE level
+	Role        string            // ..ROLE no-ref value
+	Individual  *IndividualRecord // ..ROLE ref value
+	Principal   string            // ..ROLE.PRIN
+	UnknownTags RawLines          // unhandled lines
 }
 
 // RoleRecords represents a slice of role records
@@ -724,27 +796,32 @@ type RootRecord struct {
 	Source           SourceRecords          // SOUR
 	Repository       RepositoryRecords      // REPO
 	Album            AlbumRecords           // ALBUM (MH/FTB8)
+	UnknownTags      RawLines               // unhandled lines
 	Trailer          *TrailerRecord         // TRLR
+	xrefs            *xrefTable             // xref sites read by Decode, for Integrity
 }
 
 // SchemaRecord represents a schema record
 type SchemaRecord struct {
-	Level int      // ..SCHEMA level
-	Data  []string // schema data
+	Level       int      // ..SCHEMA level
+	Data        []string // schema data
+	UnknownTags RawLines // unhandled lines
 }
 
 // ShortTitleRecord represents a short title record
 type ShortTitleRecord struct {
-	Level      int    // ..SHTI level
-	ShortTitle string // ..SHTI value
-	Indexed    string // ..SHTI.INDX
+	Level       int      // ..SHTI level
+	ShortTitle  string   // ..SHTI value
+	Indexed     string   // ..SHTI.INDX
+	UnknownTags RawLines // unhandled lines
 }
 
 // SlideShowRecord represents a slide show record (AQ14)
 type SlideShowRecord struct {
-	Level     int    // .._SSHOW level
-	Included  string // .._SSHOW value
-	ShowTime_ string // .._SSHOW._STIME value
+	Level       int      // .._SSHOW level
+	Included    string   // .._SSHOW value
+	ShowTime_   string   // .._SSHOW._STIME value
+	UnknownTags RawLines // unhandled lines
 }
 
 // SourceRecord represents a GEDCOM source record.
@@ -780,6 +857,7 @@ type SourceRecord struct {
 	Master_             string                     // ..SOUR._MASTER (AQ14)
 	Italic_             string                     // ..SOUR._ITALIC (AQ14)
 	WebTag_             *WebTagRecord              // ..SOUR._WEBTAG (Leg8)
+	UnknownTags         RawLines                   // unhandled lines
 }
 
 // SourceRecords represents a slice of source records
@@ -787,8 +865,9 @@ type SourceRecords []*SourceRecord
 
 // SubmissionLink represents a link to a submission record
 type SubmissionLink struct {
-	Level      int               // ..SUBN level
-	Submission *SubmissionRecord // target of ..SUBN
+	Level       int               // ..SUBN level
+	Submission  *SubmissionRecord // target of ..SUBN
+	UnknownTags RawLines          // unhandled lines
 }
 
 // SubmissionLinks represents a slice of links to submission records
@@ -805,6 +884,7 @@ type SubmissionRecord struct {
 	Descendents    string           // SUBN.DESC
 	Ordinance      string           // SUBN.ORDI
 	RecordInternal string           // SUBN.RecordInternal
+	UnknownTags    RawLines         // unhandled lines
 }
 
 // SubmissionRecords represents a slice of submission records
@@ -812,9 +892,10 @@ type SubmissionRecords []*SubmissionRecord
 
 // SubmitterLink represents a link to a submitter record
 type SubmitterLink struct {
-	Level     int              // ..SUBM level
-	Tag       string           // ..SUBM link tag
-	Submitter *SubmitterRecord // target of ..SUBM
+	Level       int              // ..SUBM level
+	Tag         string           // ..SUBM link tag
+	Submitter   *SubmitterRecord // target of ..SUBM
+	UnknownTags RawLines         // unhandled lines
 }
 
 // SubmitterLinks represents a slice of links to submitter records
@@ -839,6 +920,7 @@ type SubmitterRecord struct {
 	NUMB             string         // SUBM.NUMB
 	RecordInternal   string         // SUBM.RecordInternal
 	Change           *ChangeRecord  // SUBM.CHAN
+	UnknownTags      RawLines       // unhandled lines
 }
 
 // SubmitterRecords represents a slice of submitter records
@@ -853,6 +935,7 @@ type SystemRecord struct {
 	Business    *BusinessRecord // HEAD.SOUR.CORP
 	SourceData  *DataRecord     // HEAD.SOUR.DATA
 	RtlSave_    string          // HEAD.SOUR._RTLSAVE (MH/FTB8)
+	UnknownTags RawLines        // unhandled lines
 }
 
 // TitleRecords represents a slice of title links
@@ -860,9 +943,10 @@ type TitleRecords []*TitleRecord
 
 // TitleRecord represents a title record
 type TitleRecord struct {
-	Level        int    // ..TITL level
-	Title        string // ..TITL value
-	Abbreviation string // ..TITL.ABBR
+	Level        int      // ..TITL level
+	Title        string   // ..TITL value
+	Abbreviation string   // ..TITL.ABBR
+	UnknownTags  RawLines // unhandled lines
 }
 
 // TodoLink represents a link to a todo record
@@ -876,16 +960,17 @@ type TodoLinks []*TodoLink
 
 // TodoRecord represents a todo record (AQ15)
 type TodoRecord struct {
-	Level       int    // _TODO level (equal 0)
-	Xref        string // xref_id of 0 level _TODO
-	Value       string // .._TODO value
-	Description string // .._TODO.DESC
-	Priority_   string // .._TODO._PRIORITY
-	Category_   string // .._TODO._CAT
-	Type        string // .._TODO.TYPE
-	Status      string // .._TODO.STAT
-	Date        string // .._TODO.DATE
-	Date2_      string // .._TODO._DATE2
+	Level       int      // _TODO level (equal 0)
+	Xref        string   // xref_id of 0 level _TODO
+	Value       string   // .._TODO value
+	Description string   // .._TODO.DESC
+	Priority_   string   // .._TODO._PRIORITY
+	Category_   string   // .._TODO._CAT
+	Type        string   // .._TODO.TYPE
+	Status      string   // .._TODO.STAT
+	Date        string   // .._TODO.DATE
+	Date2_      string   // .._TODO._DATE2
+	UnknownTags RawLines // unhandled lines
 }
 
 // TodoRecords represents a slice of todo records
@@ -900,9 +985,10 @@ type TrailerRecord struct {
 
 // UserReferenceNumberRecord represents a user reference number record
 type UserReferenceNumberRecord struct {
-	Level               int    // ..REFN level
-	UserReferenceNumber string // ..REFN value
-	Type                string // ..REFN.TYPE
+	Level               int      // ..REFN level
+	UserReferenceNumber string   // ..REFN value
+	Type                string   // ..REFN.TYPE
+	UnknownTags         RawLines // unhandled lines
 }
 
 // UserReferenceNumberRecords represents a slice of user reference number records.
@@ -910,9 +996,10 @@ type UserReferenceNumberRecords []*UserReferenceNumberRecord
 
 // WebTagRecord represents a web tag record
 type WebTagRecord struct { // RM6
-	Level int    // .._WEBTAG level
-	Xref  string // xref_id of _WEBTAG
-	Value string // .._WEBTAG value
-	Name  string // .._WEBTAG.NAME
-	URL   string // .._WEBTAG.URL
+	Level       int      // .._WEBTAG level
+	Xref        string   // xref_id of _WEBTAG
+	Value       string   // .._WEBTAG value
+	Name        string   // .._WEBTAG.NAME
+	URL         string   // .._WEBTAG.URL
+	UnknownTags RawLines // unhandled lines
 }
@@ -6,9 +6,9 @@ information, see <http://unlicense.org/> or the accompanying UNLICENSE file.
 package gedcom
 
 import (
+	"bytes"
 	"fmt"
 	"io"
-	"log"
 	"strings"
 )
 
@@ -25,20 +25,16 @@ func LongWrite(w io.Writer, level int, xref string, tag string, longString strin
 
 	if longString == "" {
 		nbytes, err := fmt.Fprintf(w, "%s%d%s %s%s\n", indent(level), level, sXref0, tag, sXrefN)
-		if err != nil {
-			log.Fatalln("fmt.Fprintf: i/o error: ", err.Error())
-		}
-
 		return nbytes, err
 	}
 
 	parts := strings.Split(longString, "\n")
 	for i, part := range parts {
-		n, err := fmt.Fprintf(w, "%s%d%s %s%s %s\n", indent(level), level, sXref0, tag, sXrefN, part)
+		n, err := fmt.Fprintf(w, "%s%d%s %s%s %s\n", indent(level), level, sXref0, tag, sXrefN, escapeValue(part))
+		nbytes += n
 		if err != nil {
-			log.Fatalln("fmt.Fprintf: i/o error: ", err.Error())
+			return nbytes, err
 		}
-		nbytes += n
 
 		if i == 0 {
 			tag = "CONT"
@@ -60,10 +56,7 @@ func WriteLine0(w io.Writer, level int, xref string, tag string, value string) (
 	if value == "" {
 		vspacer = ""
 	}
-	n, err = fmt.Fprintf(w, "%s%d%s%s %s%s%s\n", indent(level), level, xspacer, xref, tag, vspacer, value)
-	if err != nil {
-		log.Fatalln("fmt.Fprintf: i/o error: ", err.Error())
-	}
+	n, err = fmt.Fprintf(w, "%s%d%s%s %s%s%s\n", indent(level), level, xspacer, xref, tag, vspacer, escapeValue(value))
 
 	return n, err
 }
@@ -76,9 +69,6 @@ func WriteLineLink(w io.Writer, level int, tag string, xref string) (n int, err
 		sXref = fmt.Sprintf(" %s", xref)
 	}
 	n, err = fmt.Fprintf(w, "%s%d %s%s\n", indent(level), level, tag, sXref)
-	if err != nil {
-		log.Fatalln("fmt.Fprintf: i/o error: ", err.Error())
-	}
 
 	return n, err
 }
@@ -90,10 +80,7 @@ func WriteLineN(w io.Writer, level int, tag string, value string) (n int, err er
 	if value == "" {
 		vspacer = ""
 	}
-	n, err = fmt.Fprintf(w, "%s%d %s%s%s\n", indent(level), level, tag, vspacer, value)
-	if err != nil {
-		log.Fatalln("fmt.Fprintf: i/o error: ", err.Error())
-	}
+	n, err = fmt.Fprintf(w, "%s%d %s%s%s\n", indent(level), level, tag, vspacer, escapeValue(value))
 
 	return n, err
 }
@@ -105,10 +92,7 @@ func WriteLineNp1(w io.Writer, level int, tag string, value string) (n int, err
 	if value == "" {
 		vspacer = ""
 	}
-	n, err = fmt.Fprintf(w, "%s%d %s%s%s\n", indent(level+1), level+1, tag, vspacer, value)
-	if err != nil {
-		log.Fatalln("fmt.Fprintf: i/o error: ", err.Error())
-	}
+	n, err = fmt.Fprintf(w, "%s%d %s%s%s\n", indent(level+1), level+1, tag, vspacer, escapeValue(value))
 
 	return n, err
 }
@@ -116,6 +100,7 @@ func WriteLineNp1(w io.Writer, level int, tag string, value string) (n int, err
 // Write formats and writes a GEDCOM address record
 func (r *AddressRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = LongWrite(w, r.Level, "", "ADDR", r.Full)
 	nbytes += n
@@ -170,6 +155,11 @@ func (r *AddressRecord) Write(w io.Writer) (nbytes int, err error) {
 		n, err = r.Note.Write(w)
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -188,6 +178,7 @@ func (r AddressRecords) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM album record (MH/FTB8)
 func (r *AlbumRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = LongWrite(w, r.Level, "", "ADDR", "")
 	nbytes += n
@@ -214,6 +205,11 @@ func (r *AlbumRecord) Write(w io.Writer) (nbytes int, err error) {
 		n, err = r.Photo_.Write(w)
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -232,6 +228,7 @@ func (r AlbumRecords) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM attribute records
 func (r *AttributeRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	id := ""
 	if r.Xref != "" {
@@ -241,11 +238,11 @@ func (r *AttributeRecord) Write(w io.Writer) (nbytes int, err error) {
 	if r.Value == "" {
 		spacer = ""
 	}
-	n, err = fmt.Fprintf(w, "%s%d %s%s%s%s\n", indent(r.Level), r.Level, id, r.Tag, spacer, r.Value)
+	n, err = fmt.Fprintf(w, "%s%d %s%s%s%s\n", indent(r.Level), r.Level, id, r.Tag, spacer, escapeValue(r.Value))
+	nbytes += n
 	if err != nil {
-		log.Fatalln("fmt.Fprintf: i/o error: ", err.Error())
+		return nbytes, err
 	}
-	nbytes += n
 
 	if r.UniqueId_ != nil { // MH/FTB8
 		for _, uid := range r.UniqueId_ {
@@ -402,6 +399,11 @@ func (r *AttributeRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -421,6 +423,7 @@ func (r AttributeRecords) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM author record
 func (r *AuthorRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = LongWrite(w, r.Level, "", "AUTH", r.Author)
 	nbytes += n
@@ -430,12 +433,18 @@ func (r *AuthorRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
 // Write formats and writes a GEDCOM bibliography record
 func (r *BibliographyRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLineN(w, r.Level, "BIBL", r.Value)
 	nbytes += n
@@ -445,12 +454,18 @@ func (r *BibliographyRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
 // Write formats and writes a GEDCOM blob record
 func (r *BlobRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLineN(w, r.Level, "BLOB", "")
 	nbytes += n
@@ -461,12 +476,18 @@ func (r *BlobRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
 // Write formats and writes a GEDCOM business record
 func (r *BusinessRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLineN(w, r.Level, "CORP", r.BusinessName)
 	nbytes += n
@@ -486,12 +507,18 @@ func (r *BusinessRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
 // Write formats and writes a GEDCOM call number record
 func (r *CallNumberRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLineN(w, r.Level, "CALN", r.CallNumber)
 	nbytes += n
@@ -501,12 +528,18 @@ func (r *CallNumberRecord) Write(w io.Writer) (nbytes int, err error) {
 		n, err = WriteLineNp1(w, r.Level, "MEDI", r.Media)
 		nbytes += n
 	}
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
 // Write formats and writes a GEDCOM change record
 func (r *ChangeRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLineN(w, r.Level, "CHAN", "")
 	nbytes += n
@@ -521,12 +554,18 @@ func (r *ChangeRecord) Write(w io.Writer) (nbytes int, err error) {
 		n, err = r.Note.Write(w)
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
 // Write formats and writes a GEDCOM character set record
 func (r *CharacterSetRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLineN(w, r.Level, "CHAR", r.CharacterSet)
 	nbytes += n
@@ -536,12 +575,18 @@ func (r *CharacterSetRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
 // Write formats and writes a GEDCOM child status record
 func (r *ChildStatusRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLine0(w, r.Level, r.Xref, "CSTA", "")
 	nbytes += n
@@ -549,6 +594,11 @@ func (r *ChildStatusRecord) Write(w io.Writer) (nbytes int, err error) {
 	n, err = WriteLineNp1(w, r.Level, "NAME", r.Name)
 	nbytes += n
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -568,6 +618,7 @@ func (r ChildStatusRecords) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM citatio record
 func (r *CitationRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = LongWrite(w, r.Level, r.Xref, "SOUR", r.Value)
 	nbytes += n
@@ -661,6 +712,11 @@ func (r *CitationRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -682,6 +738,7 @@ func (r CitationRecords) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM data record
 func (r *DataRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = LongWrite(w, r.Level, "", "DATA", r.Data)
 	nbytes += n
@@ -716,6 +773,11 @@ func (r *DataRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -735,6 +797,7 @@ func (r DataRecords) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM date record
 func (r *DateRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLineN(w, r.Level, r.Tag, r.Date)
 	nbytes += n
@@ -780,12 +843,18 @@ func (r *DateRecord) Write(w io.Writer) (nbytes int, err error) {
 
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
 // Write formats and writes a GEDCOM event definition record
 func (r EventDefinitionRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLineN(w, r.Level, "_EVENT_DEFN", r.Name)
 	nbytes += n
@@ -825,6 +894,11 @@ func (r EventDefinitionRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -844,6 +918,7 @@ func (r EventDefinitionRecords) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM event records
 func (r *EventRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	id := ""
 	if r.Xref != "" {
@@ -853,11 +928,11 @@ func (r *EventRecord) Write(w io.Writer) (nbytes int, err error) {
 	if r.Value == "" {
 		spacer = ""
 	}
-	n, err = fmt.Fprintf(w, "%s%d %s%s%s%s\n", indent(r.Level), r.Level, id, r.Tag, spacer, r.Value)
+	n, err = fmt.Fprintf(w, "%s%d %s%s%s%s\n", indent(r.Level), r.Level, id, r.Tag, spacer, escapeValue(r.Value))
+	nbytes += n
 	if err != nil {
-		log.Fatalln("fmt.Fprintf: i/o error: ", err.Error())
+		return nbytes, err
 	}
-	nbytes += n
 
 	if r.UniqueId_ != nil { // MH/FTB8
 		for _, uid := range r.UniqueId_ {
@@ -1007,6 +1082,11 @@ func (r *EventRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -1026,6 +1106,7 @@ func (r EventRecords) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM link to a family record
 func (r *FamilyLink) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLineLink(w, r.Level, r.Tag, r.Value)
 	nbytes += n
@@ -1055,6 +1136,11 @@ func (r *FamilyLink) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -1074,6 +1160,7 @@ func (r FamilyLinks) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM family record
 func (r *FamilyRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLine0(w, r.Level, r.Xref, "FAM", "")
 	nbytes += n
@@ -1173,6 +1260,11 @@ func (r *FamilyRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -1192,6 +1284,7 @@ func (r FamilyRecords) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM footnote record
 func (r *FootnoteRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLineN(w, r.Level, "FOOT", r.Value)
 	nbytes += n
@@ -1201,12 +1294,18 @@ func (r *FootnoteRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
 // Write formats and writes a GEDCOM gedcom record
 func (r *GedcomRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLineN(w, r.Level, "GEDC", "")
 	nbytes += n
@@ -1217,6 +1316,11 @@ func (r *GedcomRecord) Write(w io.Writer) (nbytes int, err error) {
 	n, err = WriteLineNp1(w, r.Level, "FORM", r.Form)
 	nbytes += n
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 
 }
@@ -1224,6 +1328,7 @@ func (r *GedcomRecord) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM header record
 func (r *HeaderRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLineN(w, r.Level, "HEAD", "")
 	nbytes += n
@@ -1338,12 +1443,18 @@ func (r *HeaderRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
 // Write formats and writes a GEDCOM history record
 func (r *HistoryRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLineN(w, r.Level, "HIST", r.History)
 	nbytes += n
@@ -1353,6 +1464,11 @@ func (r *HistoryRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -1371,6 +1487,7 @@ func (r HistoryRecords) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM individual links
 func (r *IndividualLink) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLineLink(w, r.Level, r.Tag, r.Individual.Xref)
 	nbytes += n
@@ -1405,6 +1522,11 @@ func (r *IndividualLink) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -1424,6 +1546,7 @@ func (r IndividualLinks) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM individual record
 func (r *IndividualRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLine0(w, r.Level, r.Xref, "INDI", "")
 	nbytes += n
@@ -1681,6 +1804,11 @@ func (r *IndividualRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -1700,10 +1828,16 @@ func (r IndividualRecords) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM media links
 func (r *MediaLink) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLineLink(w, r.Level, r.Tag, r.Media.Xref)
 	nbytes += n
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -1728,6 +1862,7 @@ func (r MediaLinks) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM media record
 func (r *MediaRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	id0, idN := "", ""
 	if r.Xref != "" {
@@ -1739,10 +1874,10 @@ func (r *MediaRecord) Write(w io.Writer) (nbytes int, err error) {
 	}
 
 	n, err = fmt.Fprintf(w, "%s%d%s %s%s\n", indent(r.Level), r.Level, id0, "OBJE", idN)
+	nbytes += n
 	if err != nil {
-		log.Fatalln("fmt.Fprintf: i/o error: ", err.Error())
+		return nbytes, err
 	}
-	nbytes += n
 
 	if true || (r.Format != "") {
 		n, err = WriteLineNp1(w, r.Level, "FORM", r.Format)
@@ -1919,6 +2054,11 @@ func (r *MediaRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -1938,6 +2078,7 @@ func (r MediaRecords) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM name record
 func (r *NameRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLineN(w, r.Level, "NAME", r.Name)
 	nbytes += n
@@ -2031,6 +2172,11 @@ func (r *NameRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -2050,6 +2196,7 @@ func (r NameRecords) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM note records
 func (r *NoteRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = LongWrite(w, r.Level, r.Xref, "NOTE", r.Note)
 	nbytes += n
@@ -2079,6 +2226,11 @@ func (r *NoteRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -2098,6 +2250,7 @@ func (r NoteRecords) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM pedigree record
 func (r *PedigreeRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLineN(w, r.Level, "PEDI", r.Pedigree)
 	nbytes += n
@@ -2112,12 +2265,18 @@ func (r *PedigreeRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
 // Write formats and writes a GEDCOM phone record
 func (r *PhoneRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLineN(w, r.Level, "PHON", r.Phone)
 	nbytes += n
@@ -2127,6 +2286,11 @@ func (r *PhoneRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -2146,6 +2310,7 @@ func (r PhoneRecords) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM photo record (MH/FTB8)
 func (r *PhotoRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLineN(w, r.Level, "_PHOTO", "")
 	nbytes += n
@@ -2160,6 +2325,11 @@ func (r *PhotoRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -2176,9 +2346,48 @@ func (r PhotoRecords) Write(w io.Writer) (nbytes int, err error) {
 	return nbytes, err
 }
 
+// Write formats and writes a GEDCOM place definition record
+func (r *PlaceDefinitionRecord) Write(w io.Writer) (nbytes int, err error) {
+	var n int
+	w, unknown := r.UnknownTags.interleave(w)
+
+	n, err = WriteLine0(w, r.Level, r.Xref, "_PLAC_DEFN", "")
+	nbytes += n
+
+	if r.Place != "" {
+		n, err = WriteLineNp1(w, r.Level, "PLAC", r.Place)
+		nbytes += n
+	}
+
+	if r.Abbreviation != "" {
+		n, err = WriteLineNp1(w, r.Level, "ABBR", r.Abbreviation)
+		nbytes += n
+	}
+
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
+	return nbytes, err
+}
+
+// Write formats and writes a slice of place definition records
+func (r PlaceDefinitionRecords) Write(w io.Writer) (nbytes int, err error) {
+	var n int
+
+	for _, x := range r {
+		n, err = x.Write(w)
+		nbytes += n
+	}
+
+	return nbytes, err
+}
+
 // Write formats and writes a GEDCOM place part record
 func (r *PlacePartRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLineN(w, r.Level, r.Tag, r.Part)
 	nbytes += n
@@ -2188,26 +2397,32 @@ func (r *PlacePartRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
 // Write formats and writes a GEDCOM place record
 func (r *PlaceRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	id := ""
 	if r.Xref != "" {
 		id = fmt.Sprintf("%s ", r.Xref)
 	}
 
-	n, err = fmt.Fprintf(w, "%s%d %s%s %s\n", indent(r.Level), r.Level, id, r.Tag, r.Name)
+	n, err = fmt.Fprintf(w, "%s%d %s%s %s\n", indent(r.Level), r.Level, id, r.Tag, escapeValue(r.Name))
+	nbytes += n
 	if err != nil {
-		log.Fatalln("fmt.Fprintf: i/o error: ", err.Error())
+		return nbytes, err
 	}
-	nbytes += n
 
 	if r.Form != "" {
-		n, err = WriteLineNp1(w, r.Level, "PLAS", r.Form)
+		n, err = WriteLineNp1(w, r.Level, "FORM", r.Form)
 		nbytes += n
 	}
 
@@ -2243,6 +2458,11 @@ func (r *PlaceRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -2262,6 +2482,7 @@ func (r PlaceRecords) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM publish record (MH/FTB8)
 func (r *PublishRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	// log.Printf("PublishRecord type(r): %T\n", r)
 
@@ -2293,6 +2514,11 @@ func (r *PublishRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -2309,9 +2535,143 @@ func (r PublishRecords) Write(w io.Writer) (nbytes int, err error) {
 	return nbytes, err
 }
 
+// Write formats and writes a raw line
+func (r *RawLine) Write(w io.Writer) (nbytes int, err error) {
+	return WriteLine0(w, r.Level, r.Xref, r.Tag, r.Value)
+}
+
+// Write formats and writes a slice of raw lines
+func (r RawLines) Write(w io.Writer) (nbytes int, err error) {
+	var n int
+
+	for _, x := range r {
+		n, err = x.Write(w)
+		nbytes += n
+	}
+
+	return nbytes, err
+}
+
+// level returns the level at which the lines of r are placed among the
+// known lines, the first of which is first: the level of the first line of
+// r, or the level below first if the first line of r was kept under a text
+// value
+func (r RawLines) level(first []byte) int {
+	level := r[0].Level
+	if l := lineLevel(first); l >= 0 && l+1 < level {
+		level = l + 1
+	}
+	return level
+}
+
+// split returns the first line of r with its subordinate lines, and the
+// lines after them
+func (r RawLines) split() (tree RawLines, rest RawLines) {
+	n := 1
+	for n < len(r) && r[n].Level > r[0].Level && r[n].Position == 0 {
+		n++
+	}
+	return r[:n], r[n:]
+}
+
+// lineLevel returns the level of a GEDCOM line, or -1 if it has none
+func lineLevel(line []byte) int {
+	i := 0
+	for i < len(line) && (line[i] == ' ' || line[i] == '\t') {
+		i++
+	}
+	if i == len(line) || line[i] < '0' || line[i] > '9' {
+		return -1
+	}
+	level := 0
+	for ; i < len(line) && line[i] >= '0' && line[i] <= '9' && level <= maxLevel; i++ {
+		level = level*10 + int(line[i]-'0')
+	}
+	return level
+}
+
+// unknownWriter writes the unknown lines of a record among the known lines
+// written to it, each before the known line which followed it in the input
+type unknownWriter struct {
+	w      io.Writer
+	lines  RawLines // unknown lines not yet written
+	level  int      // level the unknown lines are counted at
+	known  int      // known lines written at that level
+	bol    bool     // the next byte starts a line
+	begun  bool     // a known line has been written
+	nbytes int      // bytes of unknown lines written
+}
+
+// interleave returns a Writer which writes the lines of r among the lines
+// written to it, and the unknownWriter to flush, or w and nil if r is empty
+func (r RawLines) interleave(w io.Writer) (io.Writer, *unknownWriter) {
+	if len(r) == 0 {
+		return w, nil
+	}
+	u := &unknownWriter{w: w, lines: r, bol: true}
+	return u, u
+}
+
+// Write writes p, writing the unknown lines which came before each known
+// line at their level first
+func (u *unknownWriter) Write(p []byte) (nbytes int, err error) {
+	var n int
+
+	for len(p) > 0 {
+		end := bytes.IndexByte(p, '\n') + 1
+		if end == 0 {
+			end = len(p)
+		}
+		if u.bol && !u.begun {
+			u.level = u.lines.level(p[:end])
+			u.begun = true
+		}
+		if u.bol && lineLevel(p[:end]) == u.level {
+			u.known++
+			if err = u.write(u.known); err != nil {
+				return nbytes, err
+			}
+		}
+		n, err = u.w.Write(p[:end])
+		nbytes += n
+		if err != nil {
+			return nbytes, err
+		}
+		u.bol = p[end-1] == '\n'
+		p = p[end:]
+	}
+	return nbytes, err
+}
+
+// write writes the unknown lines with positions up to pos, or all of them
+// if pos is negative
+func (u *unknownWriter) write(pos int) error {
+	for len(u.lines) > 0 {
+		if pos >= 0 && (u.lines[0].Position == 0 || u.lines[0].Position > pos) {
+			return nil
+		}
+		var tree RawLines
+		tree, u.lines = u.lines.split()
+		n, err := tree.Write(u.w)
+		u.nbytes += n
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flush writes the unknown lines not yet written, and returns the number
+// of bytes of unknown lines written in all
+func (u *unknownWriter) flush() (nbytes int, err error) {
+	err = u.write(-1)
+	return u.nbytes, err
+}
+
 // Write formats and writes a GEDCOM link to a repository record
 func (r *RepositoryLink) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLineLink(w, r.Level, "REPO", r.Repository.Xref)
 	nbytes += n
@@ -2326,6 +2686,11 @@ func (r *RepositoryLink) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -2345,6 +2710,7 @@ func (r RepositoryLinks) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM repository record
 func (r *RepositoryRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLine0(w, r.Level, r.Xref, "REPO", "")
 	nbytes += n
@@ -2389,6 +2755,11 @@ func (r *RepositoryRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -2408,6 +2779,7 @@ func (r RepositoryRecords) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM role record
 func (r *RoleRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	spacer := ""
 	xref := ""
@@ -2419,17 +2791,22 @@ func (r *RoleRecord) Write(w io.Writer) (nbytes int, err error) {
 	if r.Role != "" && xref != "" {
 		spacer = " "
 	}
-	n, err = fmt.Fprintf(w, "%s%d ROLE %s%s%s\n", indent(r.Level), r.Level, r.Role, spacer, xref)
+	n, err = fmt.Fprintf(w, "%s%d ROLE %s%s%s\n", indent(r.Level), r.Level, escapeValue(r.Role), spacer, xref)
+	nbytes += n
 	if err != nil {
-		log.Fatalln("fmt.Fprintf: i/o error: ", err.Error())
+		return nbytes, err
 	}
-	nbytes += n
 
 	if r.Principal != "" {
 		n, err = WriteLineNp1(w, r.Level, "PRIN", r.Principal)
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -2449,6 +2826,7 @@ func (r RoleRecords) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM root record, i.e. the whole file
 func (r *RootRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	if r.Header != nil {
 		n, err = r.Header.Write(w)
@@ -2457,42 +2835,42 @@ func (r *RootRecord) Write(w io.Writer) (nbytes int, err error) {
 
 	// log.Printf("r.Publish_ type(r): %T\n", r.Publish_)
 	if len(r.Publish_) > 0 { // _PUBLISH (MH/FTB8)
-		r.Publish_.Write(w)
+		n, err = r.Publish_.Write(w)
 		nbytes += n
 	}
 
 	if len(r.Submission) > 0 { // SUBM
-		r.Submission.Write(w)
+		n, err = r.Submission.Write(w)
 		nbytes += n
 	}
 
 	if len(r.Submitter) > 0 {
-		r.Submitter.Write(w)
+		n, err = r.Submitter.Write(w)
 		nbytes += n
 	}
 
 	if len(r.Individual) > 0 { // INDI
-		r.Individual.Write(w)
+		n, err = r.Individual.Write(w)
 		nbytes += n
 	}
 
 	if len(r.Family) > 0 { // FAM
-		r.Family.Write(w)
+		n, err = r.Family.Write(w)
 		nbytes += n
 	}
 
 	if len(r.Note) > 0 { // NOTE
-		r.Note.Write(w)
+		n, err = r.Note.Write(w)
 		nbytes += n
 	}
 
 	if len(r.Place) > 0 { // PLAC
-		r.Place.Write(w)
+		n, err = r.Place.Write(w)
 		nbytes += n
 	}
 
 	if len(r.Event) > 0 { // EVEN
-		r.Event.Write(w)
+		n, err = r.Event.Write(w)
 		nbytes += n
 	}
 
@@ -2506,28 +2884,38 @@ func (r *RootRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if len(r.PlaceDefinition_) > 0 { // _PLAC_DEFN
+		n, err = r.PlaceDefinition_.Write(w)
+		nbytes += n
+	}
+
 	if len(r.EventDefinition_) > 0 { // _EVENT_DEFN
-		r.EventDefinition_.Write(w)
+		n, err = r.EventDefinition_.Write(w)
 		nbytes += n
 	}
 
 	if len(r.Todo_) > 0 { // _TODO
-		r.Todo_.Write(w)
+		n, err = r.Todo_.Write(w)
 		nbytes += n
 	}
 
 	if len(r.Source) > 0 { // SOUR
-		r.Source.Write(w)
+		n, err = r.Source.Write(w)
 		nbytes += n
 	}
 
 	if len(r.Repository) > 0 { // REPO
-		r.Repository.Write(w)
+		n, err = r.Repository.Write(w)
 		nbytes += n
 	}
 
 	if len(r.Album) > 0 { // ALBUM (MH/FTB8)
-		r.Album.Write(w)
+		n, err = r.Album.Write(w)
+		nbytes += n
+	}
+
+	if unknown != nil {
+		n, err = unknown.flush()
 		nbytes += n
 	}
 
@@ -2542,19 +2930,25 @@ func (r *RootRecord) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM schema record
 func (r *SchemaRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = fmt.Fprintf(w, "%s%d SCHEMA\n", indent(r.Level), r.Level)
+	nbytes += n
 	if err != nil {
-		log.Fatalln("fmt.Fprintf: i/o error: ", err.Error())
+		return nbytes, err
 	}
-	nbytes += n
 
 	for _, data := range r.Data {
-		level := int(data[0])
+		level := int(data[0] - '0')
 		n, err = fmt.Fprintf(w, "%s\n", indent(level)+data)
+		nbytes += n
 		if err != nil {
-			log.Fatalln("fmt.Fprintf: i/o error: ", err.Error())
+			return nbytes, err
 		}
+	}
+
+	if unknown != nil {
+		n, err = unknown.flush()
 		nbytes += n
 	}
 
@@ -2564,6 +2958,7 @@ func (r *SchemaRecord) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM short title record
 func (r *ShortTitleRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLineN(w, r.Level, "SHTI", r.ShortTitle)
 	nbytes += n
@@ -2573,12 +2968,18 @@ func (r *ShortTitleRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
 // Write formats and writes a GEDCOM slide show record (AQ14)
 func (r *SlideShowRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLineN(w, r.Level, "_SSHOW", r.Included)
 	nbytes += n
@@ -2588,12 +2989,18 @@ func (r *SlideShowRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
 // Write formats and writes a GEDCOM level 0 source record
 func (r *SourceRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = LongWrite(w, r.Level, r.Xref, "SOUR", r.Value)
 	nbytes += n
@@ -2734,6 +3141,11 @@ func (r *SourceRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -2752,10 +3164,16 @@ func (r SourceRecords) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM link to a submission record
 func (r *SubmissionLink) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLineLink(w, r.Level, "SUBN", r.Submission.Xref)
 	nbytes += n
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -2774,6 +3192,7 @@ func (r SubmissionLinks) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM level 0 submission record
 func (r *SubmissionRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLine0(w, r.Level, r.Xref, "SUBN", "")
 	nbytes += n
@@ -2808,6 +3227,11 @@ func (r *SubmissionRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -2827,10 +3251,16 @@ func (r SubmissionRecords) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM link to a submitter record
 func (r *SubmitterLink) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLineLink(w, r.Level, r.Tag, r.Submitter.Xref)
 	nbytes += n
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -2848,6 +3278,7 @@ func (r SubmitterLinks) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM level 0 submitter record
 func (r *SubmitterRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	var sXref0, sXrefn string
 
@@ -2857,10 +3288,10 @@ func (r *SubmitterRecord) Write(w io.Writer) (nbytes int, err error) {
 		sXref0, sXrefn = "", fmt.Sprintf(" %s", r.Xref)
 	}
 	n, err = fmt.Fprintf(w, "%s%d %sSUBM%s\n", indent(r.Level), r.Level, sXref0, sXrefn)
+	nbytes += n
 	if err != nil {
-		log.Fatalln("fmt.Fprintf: i/o error: ", err.Error())
+		return nbytes, err
 	}
-	nbytes += n
 
 	if r.Rin != nil { // MH/FTB8
 		for _, rin := range r.Rin {
@@ -2939,6 +3370,11 @@ func (r *SubmitterRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -2956,6 +3392,7 @@ func (r SubmitterRecords) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM system record
 func (r *SystemRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLineN(w, r.Level, "SOUR", r.SystemName)
 	nbytes += n
@@ -2985,12 +3422,18 @@ func (r *SystemRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
 // Write formats and writes a GEDCOM title record
 func (r *TitleRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLineN(w, r.Level, "TITL", r.Title)
 	nbytes += n
@@ -3000,6 +3443,11 @@ func (r *TitleRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -3018,6 +3466,7 @@ func (r TitleRecords) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM level 0 todo record
 func (r *TodoRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = LongWrite(w, r.Level, r.Xref, "_TODO", r.Value)
 	nbytes += n
@@ -3057,6 +3506,11 @@ func (r *TodoRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -3085,6 +3539,7 @@ func (r *TrailerRecord) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM user reference number record
 func (r *UserReferenceNumberRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = WriteLineN(w, r.Level, "REFN", r.UserReferenceNumber)
 	nbytes += n
@@ -3094,6 +3549,11 @@ func (r *UserReferenceNumberRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }
 
@@ -3112,6 +3572,7 @@ func (r UserReferenceNumberRecords) Write(w io.Writer) (nbytes int, err error) {
 // Write formats and writes a GEDCOM web tag record (RM6)
 func (r *WebTagRecord) Write(w io.Writer) (nbytes int, err error) {
 	var n int
+	w, unknown := r.UnknownTags.interleave(w)
 
 	n, err = LongWrite(w, r.Level, r.Xref, "_WEBTAG", r.Value)
 	nbytes += n
@@ -3126,5 +3587,10 @@ func (r *WebTagRecord) Write(w io.Writer) (nbytes int, err error) {
 		nbytes += n
 	}
 
+	if unknown != nil {
+		n, err = unknown.flush()
+		nbytes += n
+	}
+
 	return nbytes, err
 }